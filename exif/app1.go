@@ -1,17 +1,11 @@
 package exif
 
 import (
-	"encoding/base64"
 	"errors"
-	"fmt"
-	"log/slog"
-	"strings"
+	"io"
 	"time"
 
 	"github.com/ZanyLeonic/exif-reader/exif/helpers"
-	"github.com/ZanyLeonic/exif-reader/exif/makernotes"
-	"github.com/ZanyLeonic/exif-reader/pb"
-	"google.golang.org/protobuf/proto"
 )
 
 // APP1 IFD Tags
@@ -36,192 +30,158 @@ const (
 	XPSubject          helpers.Tag = 0x9c9f
 )
 
-func findAPP1Segment(data []byte) (int, error) {
-	// does the file have the JPEG Magic Number
-	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
-		return 0, errors.New("file is not a JPEG")
-	}
-	for i := 0; i < len(data)-1; i++ {
-		if data[i] == 0xFF && data[i+1] == 0xE1 {
-			slog.Debug("Found APP1 segment")
-			return i, nil
-		}
-	}
+// ExtractExifData detects the container format of data and decodes the EXIF and XMP metadata inside it
+func ExtractExifData(data []byte) (*helpers.PhotoExifEvidence, error) {
+	return ExtractExifDataWithOptions(data, Options{})
+}
 
-	return 0, errors.New("cannot find EXIF block")
+// ExtractExifDataWithOptions is ExtractExifData with optional behaviour such as the raw tag dump
+func ExtractExifDataWithOptions(data []byte, opts Options) (*helpers.PhotoExifEvidence, error) {
+	return extractExifData(helpers.NewBytesSource(data), opts)
 }
 
-func ExtractExifData(data []byte) (*helpers.PhotoExifEvidence, error) {
-	// Determine if we are working with a JPEG with EXIF data
-	offset, err := findAPP1Segment(data)
+// ExtractExifDataFromReader decodes the metadata of a file of the given size, reading only the
+// segments, chunks, boxes and IFDs that hold it rather than the whole file
+func ExtractExifDataFromReader(r io.ReaderAt, size int64, opts Options) (*helpers.PhotoExifEvidence, error) {
+	return extractExifData(helpers.NewSource(r, size), opts)
+}
+
+// ExtractExifDataFromReadSeeker is ExtractExifDataFromReader for streams that can seek but may not
+// support reading at an offset
+func ExtractExifDataFromReadSeeker(rs io.ReadSeeker, opts Options) (*helpers.PhotoExifEvidence, error) {
+	src, err := helpers.NewReadSeekerSource(rs)
 	if err != nil {
 		return nil, err
 	}
+	return extractExifData(src, opts)
+}
 
-	endian, err := helpers.DetermineEndianess(data, offset)
+func extractExifData(src *helpers.Source, opts Options) (*helpers.PhotoExifEvidence, error) {
+	ctx := &helpers.ParseContext{Logger: opts.Logger, RecordTags: opts.AllTags}
+
+	// Enough of the file to recognise each container's signature
+	head, err := src.Slice(0, min(src.Size(), 4<<10))
 	if err != nil {
 		return nil, err
 	}
 
-	slog.Debug("detected photo endianess from TIFF header", "endian", endian)
+	var metadata *helpers.PhotoExifEvidence
+	switch {
+	case helpers.IsTIFF(head):
+		metadata, err = extractTIFF(src, ctx)
+	case helpers.IsPNG(head):
+		metadata, err = extractPNG(src, ctx)
+	case helpers.IsWebP(head):
+		metadata, err = extractWebP(src, ctx)
+	case helpers.IsHEIF(head):
+		metadata, err = extractHEIF(src, ctx)
+	default:
+		metadata, err = extractJPEG(src, ctx)
+	}
 
-	tiffStart := offset + 10
-	ifdOffset := endian.Uint32(data[tiffStart+4 : tiffStart+8])
-	firstIfdIndex := tiffStart + int(ifdOffset)
+	if metadata != nil {
+		metadata.Tags = ctx.Tags
+		metadata.Warnings = ctx.Diagnostics
+	}
+	return metadata, err
+}
 
-	slog.Debug("First IFD Index", "first", firstIfdIndex)
+// decodeTIFFSection is decodeTIFF for a TIFF structure held in the length bytes at offset, such as a
+// JPEG segment or a WebP chunk. IFD and value pointers cannot reach the rest of the file.
+func decodeTIFFSection(src *helpers.Source, offset, length, tiffStart int, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, *helpers.ValueExtractor, error) {
+	section, err := src.Section(offset, length)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodeTIFF(section, tiffStart, ctx)
+}
 
-	entryCount := endian.Uint16(data[firstIfdIndex : firstIfdIndex+2])
-	slog.Debug("IFD entry count", "count", entryCount)
+// decodeTIFF walks IFD0 of the TIFF structure starting at tiffStart, following the EXIF and GPS
+// sub-IFD pointers and the link to the IFD1 thumbnail. All IFD offsets are relative to tiffStart.
+func decodeTIFF(src *helpers.Source, tiffStart int, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, *helpers.ValueExtractor, error) {
+	endian, ifdOffset, err := helpers.ParseTIFFHeader(src, tiffStart)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx.Log().Debug("detected photo endianess from TIFF header", "endian", endian)
+
+	firstIfdIndex := tiffStart + int(ifdOffset)
+
+	ctx.Log().Debug("First IFD Index", "first", firstIfdIndex)
 
 	metadata := helpers.PhotoExifEvidence{}
 	helper := helpers.ValueExtractor{
-		Data:      data,
+		Source:    src,
 		TiffStart: tiffStart,
 		Endian:    endian,
+		Context:   ctx,
 	}
 
-	for j := 0; j < int(entryCount); j++ {
-		entryOffset := firstIfdIndex + 2 + (j * 12)
-		entry := helpers.ParseIFDEntry(data, entryOffset, endian)
-
-		slog.Debug("IFD01 Entry",
-			"tag", fmt.Sprintf("%#x", entry.Tag),
-			"type", entry.DataType,
-			"count", entry.Count,
-			"valueOffset", entry.ValueOffset)
+	ifd0, err := helper.ReadIFD("IFD0", firstIfdIndex)
+	if err != nil && !errors.Is(err, helpers.ErrTruncated) {
+		return nil, nil, err
+	}
+	if err != nil {
+		ctx.IFDError("IFD0", firstIfdIndex, err)
+	}
+	ctx.Log().Debug("IFD entry count", "count", len(ifd0.Entries))
 
+	helper.WalkEntries("IFD0", ifd0.Entries, func(entry helpers.IFDEntry, value helpers.TagValue) {
 		switch entry.Tag {
 		case ProcessingSoftware:
-			metadata.Processing.ProcessingSoftware = helper.GetString(entry, entryOffset)
+			metadata.Processing.ProcessingSoftware = value.String()
 		case ImageWidth:
-			metadata.Image.Width = int(helper.GetUint32(entryOffset))
+			metadata.Image.Width = int(value.Int(0))
 		case ImageHeight:
-			metadata.Image.Height = int(helper.GetUint32(entryOffset))
+			metadata.Image.Height = int(value.Int(0))
 		case ImageDescription:
-			metadata.Authorship.ImageDescription = helper.GetString(entry, entryOffset)
+			metadata.Authorship.ImageDescription = value.String()
 		case Make:
-			metadata.Device.Make = helper.GetString(entry, entryOffset)
+			metadata.Device.Make = value.String()
 		case Model:
-			metadata.Device.Model = helper.GetString(entry, entryOffset)
+			metadata.Device.Model = value.String()
 		case Orientation:
-			metadata.Image.Orientation = helpers.ParseOrientationValue(helper.GetUint16(entryOffset))
+			metadata.Image.Orientation = helpers.ParseOrientationValue(uint16(value.Int(0)))
 		case Software:
-			metadata.Processing.Software = helper.GetString(entry, entryOffset)
+			metadata.Processing.Software = value.String()
 		case ModifyDate:
-			dateStr := helper.GetString(entry, entryOffset)
+			dateStr := value.String()
 			parsed, err := time.Parse("2006:01:02 15:04:05", dateStr)
 			if err != nil {
-				slog.Warn("Found ModifyDate in IFD01, however, cannot parse", "error", err)
-				continue
+				ctx.WarnTag(helpers.DiagInvalidDate, "IFD0", entry, "invalid ModifyDate: "+err.Error(), "modifyDate", dateStr)
+				return
 			}
 			metadata.Temporal.ModifyDate = parsed
 		case Artist:
-			metadata.Authorship.Artist = helper.GetString(entry, entryOffset)
+			metadata.Authorship.Artist = value.String()
 		case Copyright:
-			metadata.Authorship.Copyright = helper.GetString(entry, entryOffset)
+			metadata.Authorship.Copyright = value.String()
 		case EXIFSubIFD:
-			exifSubIfdPointer := helper.GetUint32(entryOffset)
+			exifSubIfdPointer := uint32(value.Int(0))
 			exifIfdOffset := tiffStart + int(exifSubIfdPointer)
 			ExtractExifSubIFD(exifIfdOffset, &metadata, &helper)
 		case GPSSubIFD:
-			gpsSubIfdPointer := helper.GetUint32(entryOffset)
+			gpsSubIfdPointer := uint32(value.Int(0))
 			gpsIfdOffset := tiffStart + int(gpsSubIfdPointer)
 			ExtractGPSIFD(gpsIfdOffset, &metadata, &helper)
 		case XPTitle:
-			metadata.Authorship.XPTitle = helper.GetUTF16LEString(entry, entryOffset)
+			metadata.Authorship.XPTitle = helpers.DecodeUTF16LE(value.Bytes())
 		case XPComment:
-			metadata.Authorship.XPComment = helper.GetUTF16LEString(entry, entryOffset)
+			metadata.Authorship.XPComment = helpers.DecodeUTF16LE(value.Bytes())
 		case XPAuthor:
-			metadata.Authorship.XPAuthor = helper.GetUTF16LEString(entry, entryOffset)
+			metadata.Authorship.XPAuthor = helpers.DecodeUTF16LE(value.Bytes())
 		case XPKeywords:
-			metadata.Authorship.XPKeywords = helper.GetUTF16LEString(entry, entryOffset)
+			metadata.Authorship.XPKeywords = helpers.DecodeUTF16LE(value.Bytes())
 		case XPSubject:
-			metadata.Authorship.XPSubject = helper.GetUTF16LEString(entry, entryOffset)
-		}
-	}
-
-	// TODO: Implement Samsung trailer parsing
-	//if strings.ToLower(metadata.Device.Make) == "samsung" {
-	//	parsed, err := makernotes.ParseSamsungTrailer(data)
-	//	if err != nil {
-	//		slog.Warn("Failed to parse Samsung Trailer Tags", "error", err)
-	//	} else {
-	//		metadata.Authenticity.MakerNote = *parsed
-	//	}
-	//}
-
-	// Photo doesn't need extra processing for MakerNote
-	if !strings.HasPrefix(metadata.Processing.Software, "HDR+") {
-		return &metadata, nil
-	}
-
-	output, err := helpers.ExtractXMPData(data)
-	if err != nil {
-		slog.Error("Error extracting XMP metadata", "error", err)
-		return &metadata, err
-	}
-
-	slog.Debug("Found XMP data", "xmp", output)
-	xmp := helper.DecodeXMPMeta([]byte(output))
-
-	if xmp.RDF.Description.HasExtendedXMP == "" {
-		return &metadata, nil
-	}
-
-	output, err = helpers.ExtractExtXMPData(data, xmp.RDF.Description.HasExtendedXMP)
-	if err != nil {
-		slog.Error("Error extracting XMP metadata", "error", err)
-		return &metadata, err
-	}
-
-	extXmp := helper.DecodeXMPMeta([]byte(output))
-	cleanBase64 := helpers.SanitizeBase64String(extXmp.RDF.Description.HdrPlusMakerNote)
-
-	slog.Debug("Base64 lengths", "raw", len(extXmp.RDF.Description.HdrPlusMakerNote), "cleaned", len(cleanBase64))
-
-	// Try standard encoding first
-	encrypted, err := base64.StdEncoding.DecodeString(cleanBase64)
-	if err != nil {
-		slog.Warn("StdEncoding failed, trying RawStdEncoding", "error", err)
-		// Try without padding
-		encrypted, err = base64.RawStdEncoding.DecodeString(cleanBase64)
-		if err != nil {
-			slog.Error("Failed to decode HDRPlusMakerNote with both encodings", "error", err, "cleanedLength", len(cleanBase64))
-			return &metadata, err
-		}
-	}
-
-	if string(encrypted[0:4]) == "HDRP" {
-		slog.Debug("Found Google's HDRPlus header")
-
-		decrypted, err := makernotes.DecryptHDRPBytes(encrypted[5:])
-		if err != nil {
-			return &metadata, err
-		}
-
-		protoBytes, err := makernotes.ReadGzipContent(decrypted)
-		if err != nil {
-			return &metadata, err
-		}
-
-		// Try to parse the protobuf, even if truncated
-		hdrPlusNotes := pb.GoogleHDRPlusMakerNote{}
-		unmarshalOpts := proto.UnmarshalOptions{
-			DiscardUnknown: true,
-		}
-		err = unmarshalOpts.Unmarshal(protoBytes, &hdrPlusNotes)
-		if err != nil {
-			// Like ExifTool, treat protobuf parse errors as warnings
-			// The data is likely truncated, but we can still extract other EXIF data
-			slog.Warn("Protobuf parsing incomplete - data may be truncated or corrupted", "error", err, "dataSize", len(protoBytes))
-		} else {
-			slog.Debug("Successfully parsed HDR Plus MakerNotes", "hasData", hdrPlusNotes.ProtoReflect().IsValid())
+			metadata.Authorship.XPSubject = helpers.DecodeUTF16LE(value.Bytes())
 		}
+	})
 
-		// Populate the MakerNote data in the metadata struct
-		metadata.Authenticity.MakerNote = makernotes.ConvertHDRPlusToMakerNote(&hdrPlusNotes, encrypted)
+	// IFD1 follows IFD0 in the chain and describes the embedded thumbnail
+	if ifd0.Next != 0 {
+		ExtractThumbnailIFD(tiffStart+int(ifd0.Next), &metadata, &helper)
 	}
 
-	return &metadata, nil
+	return &metadata, &helper, nil
 }
@@ -0,0 +1,65 @@
+package exif
+
+import (
+	"strconv"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+	"github.com/ZanyLeonic/exif-reader/exif/makernotes"
+)
+
+// applyDJIMakerNote fills the drone section from a DJI MakerNote. XMP, when present, is applied
+// afterwards and takes precedence.
+func applyDJIMakerNote(metadata *helpers.PhotoExifEvidence, parsed *makernotes.DJIMakerNote) {
+	drone := &metadata.Drone
+	drone.SpeedX = parsed.SpeedX
+	drone.SpeedY = parsed.SpeedY
+	drone.SpeedZ = parsed.SpeedZ
+	drone.FlightPitch = parsed.Pitch
+	drone.FlightYaw = parsed.Yaw
+	drone.FlightRoll = parsed.Roll
+	drone.GimbalPitch = parsed.CameraPitch
+	drone.GimbalYaw = parsed.CameraYaw
+	drone.GimbalRoll = parsed.CameraRoll
+}
+
+// applyDroneXMP fills the drone section from the drone-dji XMP attributes that are present
+func applyDroneXMP(ctx *helpers.ParseContext, metadata *helpers.PhotoExifEvidence, dji helpers.DroneDJIXMP) {
+	drone := &metadata.Drone
+	for _, field := range []struct {
+		name string
+		raw  string
+		dst  *float64
+	}{
+		{"AbsoluteAltitude", dji.AbsoluteAltitude, &drone.AbsoluteAltitude},
+		{"RelativeAltitude", dji.RelativeAltitude, &drone.RelativeAltitude},
+		{"GimbalYawDegree", dji.GimbalYawDegree, &drone.GimbalYaw},
+		{"GimbalPitchDegree", dji.GimbalPitchDegree, &drone.GimbalPitch},
+		{"GimbalRollDegree", dji.GimbalRollDegree, &drone.GimbalRoll},
+		{"FlightYawDegree", dji.FlightYawDegree, &drone.FlightYaw},
+		{"FlightPitchDegree", dji.FlightPitchDegree, &drone.FlightPitch},
+		{"FlightRollDegree", dji.FlightRollDegree, &drone.FlightRoll},
+		{"FlightXSpeed", dji.FlightXSpeed, &drone.SpeedX},
+		{"FlightYSpeed", dji.FlightYSpeed, &drone.SpeedY},
+		{"FlightZSpeed", dji.FlightZSpeed, &drone.SpeedZ},
+		{"CalibratedFocalLength", dji.CalibratedFocalLength, &drone.CalibratedFocalLength},
+	} {
+		if field.raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(field.raw, 64)
+		if err != nil {
+			ctx.Warn(helpers.DiagInvalidValue, "XMP", -1, "invalid drone-dji value", "field", field.name, "value", field.raw)
+			continue
+		}
+		*field.dst = value
+	}
+
+	if dji.RtkFlag != "" {
+		flag, err := strconv.Atoi(dji.RtkFlag)
+		if err != nil {
+			ctx.Warn(helpers.DiagInvalidValue, "XMP", -1, "invalid drone-dji value", "field", "RtkFlag", "value", dji.RtkFlag)
+			return
+		}
+		drone.RtkFlag = flag
+	}
+}
@@ -0,0 +1,113 @@
+package exif
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"math"
+	"testing"
+)
+
+// djiJPEG builds a JPEG from a DJI aircraft, with the attitude in both the MakerNote and the
+// drone-dji XMP attributes
+func djiJPEG(xmpAttributes string) []byte {
+	le := binary.LittleEndian
+	float := func(v float32) []byte { return le.AppendUint32(nil, math.Float32bits(v)) }
+
+	// Every value fits in its entry, so the MakerNote IFD stands alone
+	makerNote := le.AppendUint16(nil, 7)
+	for _, entry := range []tiffEntry{
+		{0x0001, 2, 4, []byte("DJI\x00")},
+		{0x0003, 11, 1, float(1.5)},
+		{0x0006, 11, 1, float(-2)},
+		{0x0007, 11, 1, float(90)},
+		{0x0008, 11, 1, float(0.5)},
+		{0x0009, 11, 1, float(-45)},
+		{0x000a, 11, 1, float(91)},
+	} {
+		makerNote = le.AppendUint16(makerNote, entry.tag)
+		makerNote = le.AppendUint16(makerNote, entry.dataType)
+		makerNote = le.AppendUint32(makerNote, entry.count)
+		makerNote = append(makerNote, entry.value...)
+	}
+	makerNote = le.AppendUint32(makerNote, 0)
+
+	tiff := buildTIFF(le, func(pointer func(int) []byte) [][]tiffEntry {
+		return [][]tiffEntry{
+			{
+				{0x010f, 2, 4, []byte("DJI\x00")},
+				{0x0110, 2, 8, []byte("FC3582\x00\x00")},
+				{0x8769, 4, 1, pointer(2)},
+			},
+			{
+				{0x0103, 3, 1, le.AppendUint16(nil, 6)},
+			},
+			{
+				{0x927c, 7, uint32(len(makerNote)), makerNote},
+			},
+		}
+	})
+	jpeg := wrapJPEG(tiff)
+
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:drone-dji="http://www.dji.com/drone-dji/1.0/" ` + xmpAttributes + `/></rdf:RDF></x:xmpmeta>`
+	payload := append([]byte("http://ns.adobe.com/xap/1.0/\x00"), packet...)
+	segment := binary.BigEndian.AppendUint16([]byte{0xff, 0xe1}, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	return append(jpeg[:len(jpeg)-2], append(segment, 0xff, 0xd9)...)
+}
+
+func TestDroneMetadata(t *testing.T) {
+	data := djiJPEG(`drone-dji:AbsoluteAltitude="+152.31" drone-dji:RelativeAltitude="+60.10" ` +
+		`drone-dji:GimbalYawDegree="+91.30" drone-dji:GimbalPitchDegree="-90.00" drone-dji:GimbalRollDegree="+0.00" ` +
+		`drone-dji:FlightYawDegree="+90.40" drone-dji:FlightPitchDegree="-2.10" drone-dji:FlightRollDegree="+0.60" ` +
+		`drone-dji:FlightYSpeed="-0.20" drone-dji:FlightZSpeed="+0.10" drone-dji:RtkFlag="50" ` +
+		`drone-dji:CalibratedFocalLength="3666.666504"`)
+
+	metadata, err := ExtractExifDataWithOptions(data, Options{Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Authenticity.MakerNote.Manufacturer != "DJI" {
+		t.Errorf("MakerNote manufacturer = %q", metadata.Authenticity.MakerNote.Manufacturer)
+	}
+
+	drone := metadata.Drone
+	for name, got := range map[string][2]float64{
+		"AbsoluteAltitude":      {drone.AbsoluteAltitude, 152.31},
+		"RelativeAltitude":      {drone.RelativeAltitude, 60.1},
+		"GimbalYaw":             {drone.GimbalYaw, 91.3},
+		"GimbalPitch":           {drone.GimbalPitch, -90},
+		"FlightYaw":             {drone.FlightYaw, 90.4},
+		"FlightPitch":           {drone.FlightPitch, -2.1},
+		"FlightRoll":            {drone.FlightRoll, 0.6},
+		"SpeedY":                {drone.SpeedY, -0.2},
+		"SpeedZ":                {drone.SpeedZ, 0.1},
+		"CalibratedFocalLength": {drone.CalibratedFocalLength, 3666.666504},
+		// Only in the MakerNote
+		"SpeedX": {drone.SpeedX, 1.5},
+	} {
+		if got[0] != got[1] {
+			t.Errorf("%s = %v, want %v", name, got[0], got[1])
+		}
+	}
+	if drone.RtkFlag != 50 {
+		t.Errorf("RtkFlag = %d, want 50", drone.RtkFlag)
+	}
+}
+
+func TestDroneMetadataInvalidXMP(t *testing.T) {
+	data := djiJPEG(`drone-dji:GimbalPitchDegree="level" drone-dji:RelativeAltitude="+12.5"`)
+
+	metadata, err := ExtractExifDataWithOptions(data, Options{Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The MakerNote value stands when the XMP one cannot be read
+	if metadata.Drone.GimbalPitch != -45 || metadata.Drone.RelativeAltitude != 12.5 {
+		t.Errorf("drone = %+v", metadata.Drone)
+	}
+	if len(metadata.Warnings) != 1 || metadata.Warnings[0].Code != "invalid_value" {
+		t.Errorf("want one invalid_value warning, got %v", metadata.Warnings)
+	}
+}
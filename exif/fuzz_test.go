@@ -0,0 +1,167 @@
+package exif
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tiffEntry is an IFD entry for building synthetic TIFF seeds. Values longer than 4 bytes are
+// placed after the IFDs.
+type tiffEntry struct {
+	tag      uint16
+	dataType uint16
+	count    uint32
+	value    []byte
+}
+
+// buildTIFF lays out the IFDs back to back after the header, linking the first two as IFD0 and IFD1.
+// pointer returns the TIFF offset of IFD n so entries can refer to later IFDs; pointer(len(ifds)) is
+// the end of the structure, where the caller may append data such as a thumbnail.
+func buildTIFF(order binary.AppendByteOrder, ifds func(pointer func(n int) []byte) [][]tiffEntry) []byte {
+	u32 := func(v uint32) []byte { return order.AppendUint32(nil, v) }
+
+	layout := ifds(func(int) []byte { return u32(0) })
+	offsets := make([]uint32, len(layout)+1)
+	pos := uint32(8)
+	for i, entries := range layout {
+		offsets[i] = pos
+		pos += 2 + uint32(len(entries))*12 + 4
+	}
+	end := pos
+	for _, entries := range layout {
+		for _, entry := range entries {
+			if len(entry.value) > 4 {
+				end += uint32(len(entry.value) + len(entry.value)%2)
+			}
+		}
+	}
+	offsets[len(layout)] = end
+	layout = ifds(func(n int) []byte { return u32(offsets[n]) })
+
+	out := []byte("MM\x00\x2a")
+	if order == binary.LittleEndian {
+		out = []byte("II\x2a\x00")
+	}
+	out = append(out, u32(8)...)
+
+	var blob []byte
+	for i, entries := range layout {
+		out = order.AppendUint16(out, uint16(len(entries)))
+		for _, entry := range entries {
+			out = order.AppendUint16(out, entry.tag)
+			out = order.AppendUint16(out, entry.dataType)
+			out = order.AppendUint32(out, entry.count)
+			if len(entry.value) <= 4 {
+				out = append(out, append(entry.value, make([]byte, 4-len(entry.value))...)...)
+				continue
+			}
+			out = append(out, u32(pos+uint32(len(blob)))...)
+			blob = append(blob, entry.value...)
+			if len(blob)%2 == 1 {
+				blob = append(blob, 0)
+			}
+		}
+
+		next := uint32(0)
+		if i == 0 && len(layout) > 1 {
+			next = offsets[1]
+		}
+		out = append(out, u32(next)...)
+	}
+
+	return append(out, blob...)
+}
+
+// syntheticTIFF builds IFD0, IFD1 with a tiny JPEG thumbnail, and the Exif, GPS and Interop IFDs
+func syntheticTIFF(order binary.AppendByteOrder) []byte {
+	u16 := func(v ...uint16) []byte {
+		var b []byte
+		for _, x := range v {
+			b = order.AppendUint16(b, x)
+		}
+		return b
+	}
+	rational := func(v ...uint32) []byte {
+		var b []byte
+		for _, x := range v {
+			b = order.AppendUint32(b, x)
+			b = order.AppendUint32(b, 1)
+		}
+		return b
+	}
+	thumbnail := []byte{0xff, 0xd8, 0xff, 0xc0, 0x00, 0x0b, 0x08, 0x00, 0x10, 0x00, 0x20, 0x01, 0x01, 0x11, 0x00, 0xff, 0xd9}
+
+	tiff := buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+		return [][]tiffEntry{
+			{
+				{0x010f, 2, 6, []byte("Apple\x00")},
+				{0x0110, 2, 10, []byte("iPhone 15\x00")},
+				{0x0112, 3, 1, u16(6)},
+				{0x0131, 2, 9, []byte("HDR+ 1.0\x00")},
+				{0x0132, 2, 20, []byte("2024:10:01 14:58:52\x00")},
+				{0x8769, 4, 1, pointer(2)},
+				{0x8825, 4, 1, pointer(3)},
+			},
+			{
+				{0x0103, 3, 1, u16(6)},
+				{0x0201, 4, 1, pointer(5)},
+				{0x0202, 4, 1, order.AppendUint32(nil, uint32(len(thumbnail)))},
+			},
+			{
+				{0x829d, 5, 1, rational(2)},
+				{0x9003, 2, 20, []byte("2024:10:01 14:58:52\x00")},
+				{0x9000, 7, 4, []byte("0232")},
+				{0xa005, 4, 1, pointer(4)},
+			},
+			{
+				{0x0001, 2, 2, []byte("N\x00")},
+				{0x0002, 5, 3, rational(51, 30, 15)},
+				{0x0003, 2, 2, []byte("W\x00")},
+				{0x0004, 5, 3, rational(0, 7, 40)},
+				{0x001d, 2, 11, []byte("2024:10:01\x00")},
+			},
+			{
+				{0x0001, 2, 4, []byte("R98\x00")},
+			},
+		}
+	})
+	return append(tiff, thumbnail...)
+}
+
+// wrapJPEG places a TIFF structure in an APP1 Exif segment of an otherwise empty JPEG
+func wrapJPEG(tiff []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	out := []byte{0xff, 0xd8, 0xff, 0xe1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, 0xff, 0xd9)
+}
+
+func FuzzExtractExifData(f *testing.F) {
+	if example, err := os.ReadFile(filepath.Join("..", "example.jpg")); err == nil {
+		f.Add(example[:98<<10])
+	}
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		tiff := syntheticTIFF(order)
+		f.Add(tiff)
+		f.Add(wrapJPEG(tiff))
+	}
+	f.Add(djiJPEG(`drone-dji:GimbalPitchDegree="-90.00" drone-dji:RtkFlag="50"`))
+	paths, _ := filepath.Glob(filepath.Join("testdata", "hostile", "*"))
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			f.Add(data)
+		}
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		metadata, err := ExtractExifDataWithOptions(data, Options{AllTags: true, Logger: logger})
+		if metadata == nil && err == nil {
+			t.Fatal("no metadata and no error")
+		}
+	})
+}
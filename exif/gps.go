@@ -2,7 +2,6 @@ package exif
 
 import (
 	"fmt"
-	"log/slog"
 	"time"
 
 	"github.com/ZanyLeonic/exif-reader/exif/helpers"
@@ -40,114 +39,113 @@ type GPSIntermediateData struct {
 }
 
 func ExtractGPSIFD(exifIfdOffset int, metadata *helpers.PhotoExifEvidence, helper *helpers.ValueExtractor) {
-	entryCount := helper.Endian.Uint16(helper.Data[exifIfdOffset : exifIfdOffset+2])
-
 	var hours, minutes int
 	var seconds, speed, imgDir, destBearing, destDistance float64
 	var latRef, longRef, imgDirRef, destLatRef, destLongRef, destBearingRef, destDistanceRef, dateStr, speedMetric string
 	var hasLat, hasLong, hasDestLat, hasDestLong, hasTime, hasSpeed, hasImgDir, hasDestBearing, hasDestDistance, underSeaLevel bool
+	// Kept so range checks after the loop can point at the offending entry
+	entries := map[helpers.Tag]helpers.IFDEntry{}
 
-	for j := 0; j < int(entryCount); j++ {
-		entryOffset := exifIfdOffset + 2 + (j * 12)
-		entry := helpers.ParseIFDEntry(helper.Data, entryOffset, helper.Endian)
-
-		slog.Debug("GPS IFD Entry",
-			"tag", fmt.Sprintf("%#x", entry.Tag),
-			"type", entry.DataType,
-			"count", entry.Count,
-			"valueOffset", entry.ValueOffset)
+	_, err := helper.WalkIFD("GPS", exifIfdOffset, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		entries[entry.Tag] = entry
 
 		switch entry.Tag {
 		case GPSVersionID:
-			rawVersion := helper.GetUint8Array(entryOffset, 4)
+			rawVersion := value.Bytes()
+			if len(rawVersion) != 4 {
+				return
+			}
 			metadata.GPS.Version = fmt.Sprintf("%d.%d.%d.%d", rawVersion[0], rawVersion[1], rawVersion[2], rawVersion[3])
 		case LatitudeRef:
-			latRef = helper.GetString(entry, entryOffset)
+			latRef = value.String()
 		case Latitude:
-			metadata.GPS.Latitude = helper.GetGPSCoord(entry)
+			metadata.GPS.Latitude = gpsCoordinate(value)
 			hasLat = true
 		case LongitudeRef:
-			longRef = helper.GetString(entry, entryOffset)
+			longRef = value.String()
 		case Longitude:
-			metadata.GPS.Longitude = helper.GetGPSCoord(entry)
+			metadata.GPS.Longitude = gpsCoordinate(value)
 			hasLong = true
 		case AltitudeRef:
-			ref := helper.GetUint8(entryOffset)
+			ref := uint8(value.Int(0))
 			if ref == 1 || ref == 3 {
 				underSeaLevel = true
 			}
 		case Altitude:
-			metadata.GPS.Altitude = helper.GetRational(entry, 0, false)
+			metadata.GPS.Altitude = value.Float(0)
 		case Timestamp:
-			hours = int(helper.GetRational(entry, 0, false))
-			minutes = int(helper.GetRational(entry, 8, false))
-			seconds = helper.GetRational(entry, 16, false)
+			hours = int(value.Float(0))
+			minutes = int(value.Float(1))
+			seconds = value.Float(2)
 			hasTime = true
 		case SpeedRef:
-			speedMetric = helper.GetString(entry, entryOffset)
+			speedMetric = value.String()
 			hasSpeed = true
 		case Speed:
-			speed = helper.GetRational(entry, 0, false)
+			speed = value.Float(0)
 		case ImgDirectionRef:
-			imgDirRef = helper.GetString(entry, entryOffset)
+			imgDirRef = value.String()
 		case ImgDirection:
-			imgDir = helper.GetRational(entry, 0, false)
+			imgDir = value.Float(0)
 			hasImgDir = true
 		case MapDatum:
-			metadata.GPS.MapDatum = helper.GetString(entry, entryOffset)
+			metadata.GPS.MapDatum = value.String()
 		case DestLatitudeRef:
-			destLatRef = helper.GetString(entry, entryOffset)
+			destLatRef = value.String()
 		case DestLatitude:
-			metadata.GPS.DestinationLatitude = helper.GetGPSCoord(entry)
+			metadata.GPS.DestinationLatitude = gpsCoordinate(value)
 			hasDestLat = true
 		case DestLongitudeRef:
-			destLongRef = helper.GetString(entry, entryOffset)
+			destLongRef = value.String()
 		case DestLongitude:
-			metadata.GPS.DestinationLongitude = helper.GetGPSCoord(entry)
+			metadata.GPS.DestinationLongitude = gpsCoordinate(value)
 			hasDestLong = true
 		case DestBearingRef:
-			destBearingRef = helper.GetString(entry, entryOffset)
+			destBearingRef = value.String()
 		case DestBearing:
-			destBearing = helper.GetRational(entry, 0, false)
+			destBearing = value.Float(0)
 			hasDestBearing = true
 		case DestDistanceRef:
-			destDistanceRef = helper.GetString(entry, entryOffset)
+			destDistanceRef = value.String()
 		case DestDistance:
-			destDistance = helper.GetRational(entry, 0, false)
+			destDistance = value.Float(0)
 			hasDestDistance = true
 		case ProcessingMethod:
-			metadata.GPS.ProcessingMethod = helper.GetString(entry, entryOffset)
+			metadata.GPS.ProcessingMethod = value.String()
 		case Datestamp:
-			dateStr = helper.GetString(entry, entryOffset)
+			dateStr = value.String()
 		case Differential:
-			rawVal := helper.GetUint16(entryOffset)
+			rawVal := uint16(value.Int(0))
 			if rawVal == 0x1 {
 				metadata.GPS.Differential = "Differential Corrected"
 			} else {
 				metadata.GPS.Differential = "No Correction"
 			}
 		}
+	})
+	if err != nil {
+		helper.Context.IFDError("GPS", exifIfdOffset, err)
 	}
 
 	if hasLat && latRef == "S" {
 		metadata.GPS.Latitude *= -1
 	}
 	if hasLat && (metadata.GPS.Latitude < -90 || metadata.GPS.Latitude > 90) {
-		slog.Warn("GPS latitude out of valid range", "lat", metadata.GPS.Latitude)
+		helper.Context.WarnTag(helpers.DiagInvalidValue, "GPS", entries[Latitude], "GPS latitude out of valid range", "lat", metadata.GPS.Latitude)
 	}
 
 	if hasLong && longRef == "W" {
 		metadata.GPS.Longitude *= -1
 	}
 	if hasLong && (metadata.GPS.Longitude < -180 || metadata.GPS.Longitude > 180) {
-		slog.Warn("GPS longitude out of valid range", "long", metadata.GPS.Longitude)
+		helper.Context.WarnTag(helpers.DiagInvalidValue, "GPS", entries[Longitude], "GPS longitude out of valid range", "long", metadata.GPS.Longitude)
 	}
 
 	if underSeaLevel {
 		metadata.GPS.Altitude *= -1
 	}
 	if metadata.GPS.Altitude < -11000 || metadata.GPS.Altitude > 9000 {
-		slog.Warn("GPS altitude out of valid range", "alt", metadata.GPS.Altitude)
+		helper.Context.WarnTag(helpers.DiagInvalidValue, "GPS", entries[Altitude], "GPS altitude out of valid range", "alt", metadata.GPS.Altitude)
 	}
 
 	if hasSpeed && speedMetric != "" {
@@ -162,14 +160,14 @@ func ExtractGPSIFD(exifIfdOffset int, metadata *helpers.PhotoExifEvidence, helpe
 		metadata.GPS.DestinationLatitude *= -1
 	}
 	if hasDestLat && (metadata.GPS.DestinationLatitude < -90 || metadata.GPS.DestinationLatitude > 90) {
-		slog.Warn("GPS Destination latitude out of valid range", "lat", metadata.GPS.DestinationLatitude)
+		helper.Context.WarnTag(helpers.DiagInvalidValue, "GPS", entries[DestLatitude], "GPS destination latitude out of valid range", "lat", metadata.GPS.DestinationLatitude)
 	}
 
 	if hasDestLong && destLongRef == "W" {
 		metadata.GPS.DestinationLongitude *= -1
 	}
 	if hasDestLong && (metadata.GPS.DestinationLongitude < -180 || metadata.GPS.DestinationLongitude > 180) {
-		slog.Warn("GPS destination longitude out of valid range", "long", metadata.GPS.DestinationLongitude)
+		helper.Context.WarnTag(helpers.DiagInvalidValue, "GPS", entries[DestLongitude], "GPS destination longitude out of valid range", "long", metadata.GPS.DestinationLongitude)
 	}
 
 	if hasDestBearing && destBearingRef != "" {
@@ -181,7 +179,9 @@ func ExtractGPSIFD(exifIfdOffset int, metadata *helpers.PhotoExifEvidence, helpe
 
 	if hasTime && dateStr != "" {
 		date, err := time.Parse("2006:01:02", dateStr)
-		if err == nil {
+		if err != nil {
+			helper.Context.WarnTag(helpers.DiagInvalidDate, "GPS", entries[Datestamp], "invalid GPS date stamp: "+err.Error())
+		} else {
 			metadata.GPS.Timestamp = time.Date(
 				date.Year(), date.Month(), date.Day(),
 				hours, minutes, int(seconds),
@@ -189,3 +189,8 @@ func ExtractGPSIFD(exifIfdOffset int, metadata *helpers.PhotoExifEvidence, helpe
 		}
 	}
 }
+
+// gpsCoordinate converts a degrees, minutes, seconds rational triple to decimal degrees
+func gpsCoordinate(value helpers.TagValue) float64 {
+	return value.Float(0) + (value.Float(1) / 60.0) + (value.Float(2) / 3600.0)
+}
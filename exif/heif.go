@@ -0,0 +1,68 @@
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// extractHEIF locates the Exif and XMP items of a HEIC/HEIF file and decodes them with the same
+// IFD pipeline used for JPEG files
+func extractHEIF(src *helpers.Source, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, error) {
+	heif, err := helpers.ReadHEIF(src)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Log().Debug("Parsed HEIF container",
+		"majorBrand", heif.MajorBrand,
+		"primaryItem", heif.PrimaryItemID,
+		"items", len(heif.Items))
+
+	item, ok := heif.FindItem("Exif", "")
+	if !ok {
+		return nil, errors.New("cannot find EXIF item")
+	}
+
+	exifData, fileOffset, err := heif.ItemData(src, item)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Exif item starts with a 32-bit offset from the end of this field to the TIFF header,
+	// which skips the "Exif\0\0" signature when present
+	if len(exifData) < 4 {
+		return nil, errors.New("EXIF item too short")
+	}
+	headerOffset := int(binary.BigEndian.Uint32(exifData[0:4]))
+	if headerOffset > len(exifData)-4 {
+		return nil, fmt.Errorf("EXIF item TIFF header offset %d out of range", headerOffset)
+	}
+
+	// Decode in place when the item is contiguous in the file so offsets stay file-relative
+	source, offset := helpers.NewBytesSource(exifData), 0
+	if fileOffset >= 0 {
+		source, offset = src, fileOffset
+	}
+	tiffStart := offset + 4 + headerOffset
+
+	ctx.Log().Debug("Found EXIF item", "item", item.ID, "tiffStart", tiffStart)
+
+	metadata, _, err := decodeTIFFSection(source, offset, len(exifData), tiffStart, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if xmpItem, ok := heif.FindItem("mime", "application/rdf+xml"); ok {
+		xmpData, _, err := heif.ItemData(src, xmpItem)
+		if err != nil {
+			ctx.Warn(helpers.DiagOutOfRange, "HEIF", -1, "cannot read XMP item: "+err.Error(), "item", xmpItem.ID)
+		} else {
+			applyXMP(ctx, metadata, string(xmpData))
+		}
+	}
+
+	return metadata, nil
+}
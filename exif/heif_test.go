@@ -0,0 +1,132 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// heifBox encodes an ISO-BMFF box, and heifFullBox one with a version and zero flags
+func heifBox(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	out := append(binary.BigEndian.AppendUint32(nil, uint32(size)), boxType...)
+	for _, p := range payload {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func heifFullBox(boxType string, version byte, payload ...[]byte) []byte {
+	return heifBox(boxType, append([][]byte{{version, 0, 0, 0}}, payload...)...)
+}
+
+// heifTestItem describes an item for heifFile. Extents of items stored in the file count from the
+// start of the mdat payload, and those of items stored in idat from the start of idat.
+type heifTestItem struct {
+	id           uint16
+	itemType     string
+	contentType  string
+	construction uint16
+	extents      [][2]uint32
+	// describes is the target of a cdsc reference, if any
+	describes uint16
+}
+
+// heifFile builds a HEIF file with an ftyp box, a meta box declaring the items with primary item 1,
+// and an mdat box
+func heifFile(items []heifTestItem, idat, mdat []byte) []byte {
+	ftyp := heifBox("ftyp", []byte("heic"), make([]byte, 4), []byte("mif1heic"))
+
+	meta := func(mdatStart uint32) []byte {
+		var infe, iref, iloc [][]byte
+		for _, item := range items {
+			entry := binary.BigEndian.AppendUint16(nil, item.id)
+			entry = append(binary.BigEndian.AppendUint16(entry, 0), item.itemType...)
+			entry = append(entry, "item\x00"...)
+			if item.itemType == "mime" {
+				entry = append(append(entry, item.contentType...), 0)
+			}
+			infe = append(infe, heifFullBox("infe", 2, entry))
+
+			if item.describes != 0 {
+				ref := binary.BigEndian.AppendUint16(nil, item.id)
+				ref = binary.BigEndian.AppendUint16(ref, 1)
+				iref = append(iref, heifBox("cdsc", binary.BigEndian.AppendUint16(ref, item.describes)))
+			}
+
+			loc := binary.BigEndian.AppendUint16(nil, item.id)
+			loc = binary.BigEndian.AppendUint16(loc, item.construction)
+			loc = binary.BigEndian.AppendUint16(loc, 0)
+			loc = binary.BigEndian.AppendUint16(loc, uint16(len(item.extents)))
+			for _, extent := range item.extents {
+				offset := extent[0]
+				if item.construction == 0 {
+					offset += mdatStart
+				}
+				loc = binary.BigEndian.AppendUint32(loc, offset)
+				loc = binary.BigEndian.AppendUint32(loc, extent[1])
+			}
+			iloc = append(iloc, loc)
+		}
+
+		return heifFullBox("meta", 0,
+			heifFullBox("pitm", 0, []byte{0, 1}),
+			heifFullBox("iinf", 0, append([][]byte{binary.BigEndian.AppendUint16(nil, uint16(len(items)))}, infe...)...),
+			heifFullBox("iref", 0, iref...),
+			heifFullBox("iloc", 1, append([][]byte{{0x44, 0x00}, binary.BigEndian.AppendUint16(nil, uint16(len(items)))}, iloc...)...),
+			heifBox("idat", idat),
+		)
+	}
+
+	// Offsets do not change the size of the meta box, so it is laid out once to find where mdat starts
+	mdatStart := uint32(len(ftyp) + len(meta(0)) + 8)
+	out := append(ftyp, meta(mdatStart)...)
+	return append(out, heifBox("mdat", mdat)...)
+}
+
+func TestExtractHEIF(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		// The Exif item starts with the offset to the TIFF header, which skips the signature
+		exif := append([]byte{0, 0, 0, 6}, "Exif\x00\x00"...)
+		exif = append(exif, evidenceTIFF(order)...)
+		n := uint32(len(exif))
+		xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"></x:xmpmeta>`)
+		image := heifTestItem{id: 1, itemType: "hvc1"}
+
+		tests := []struct {
+			name  string
+			items []heifTestItem
+			idat  []byte
+			mdat  []byte
+		}{
+			{"file offset", []heifTestItem{
+				image,
+				{id: 2, itemType: "Exif", extents: [][2]uint32{{0, n}}, describes: 1},
+			}, nil, exif},
+			{"split extents", []heifTestItem{
+				image,
+				{id: 2, itemType: "Exif", extents: [][2]uint32{{n - 20, 20}, {0, n - 20}}, describes: 1},
+			}, nil, append(append([]byte{}, exif[20:]...), exif[:20]...)},
+			{"idat", []heifTestItem{
+				image,
+				{id: 2, itemType: "Exif", construction: 1, extents: [][2]uint32{{0, n}}, describes: 1},
+			}, exif, nil},
+			// An Exif item of another image comes first, and the one describing the primary image wins
+			{"primary image preferred", []heifTestItem{
+				image,
+				{id: 2, itemType: "Exif", extents: [][2]uint32{{n, 8}}, describes: 9},
+				{id: 3, itemType: "Exif", extents: [][2]uint32{{0, n}}, describes: 1},
+				{id: 4, itemType: "mime", contentType: "application/rdf+xml", extents: [][2]uint32{{n + 8, uint32(len(xmp))}}},
+			}, nil, append(append(append([]byte{}, exif...), "garbage!"...), xmp...)},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%v/%s", order, tt.name), func(t *testing.T) {
+				metadata, err := ExtractExifData(heifFile(tt.items, tt.idat, tt.mdat))
+				checkEvidence(t, metadata, err)
+			})
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+// BinaryPlistSignature starts every Apple binary property list
+const BinaryPlistSignature = "bplist00"
+
+// bplistMaxDepth limits how deeply arrays and dictionaries may nest, and bplistMaxObjects how many
+// objects are decoded, as objects can be shared and a small file can otherwise expand without bound
+const (
+	bplistMaxDepth   = 32
+	bplistMaxObjects = 1 << 16
+)
+
+// plistEpoch is the reference date of plist dates
+var plistEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// bplistDecoder Decodes the objects of a binary property list, which are stored once and referred to
+// by their index in an offset table
+type bplistDecoder struct {
+	data    []byte
+	end     int // objects lie before the offset table
+	offsets []int
+	refSize int
+	decoded int
+}
+
+// DecodeBinaryPlist decodes an Apple binary property list. Dictionaries decode to
+// map[string]interface{}, arrays to []interface{}, integers to int64 (uint64 for UIDs), reals to
+// float64, dates to time.Time, data to []byte and strings to string.
+func DecodeBinaryPlist(data []byte) (interface{}, error) {
+	if len(data) < len(BinaryPlistSignature)+32 || string(data[:len(BinaryPlistSignature)]) != BinaryPlistSignature {
+		return nil, errors.New("not a binary plist")
+	}
+
+	// The trailer holds the sizes of offsets and object references, the object count, the index of
+	// the top object and the position of the offset table
+	trailer := data[len(data)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	count := binary.BigEndian.Uint64(trailer[8:16])
+	top := binary.BigEndian.Uint64(trailer[16:24])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:32])
+
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 {
+		return nil, fmt.Errorf("invalid binary plist trailer: offset size %d, reference size %d", offsetSize, refSize)
+	}
+	tableEnd := uint64(len(data) - 32)
+	if count == 0 || count > tableEnd || tableOffset < uint64(len(BinaryPlistSignature)) || tableOffset > tableEnd ||
+		count*uint64(offsetSize) > tableEnd-tableOffset {
+		return nil, fmt.Errorf("binary plist offset table out of range: %d objects at %d", count, tableOffset)
+	}
+	if top >= count {
+		return nil, fmt.Errorf("binary plist top object %d out of range", top)
+	}
+
+	d := bplistDecoder{data: data, end: int(tableOffset), offsets: make([]int, count), refSize: refSize}
+	for i := range d.offsets {
+		pos := int(tableOffset) + i*offsetSize
+		offset := readUintBE(data[pos : pos+offsetSize])
+		if offset < uint64(len(BinaryPlistSignature)) || offset >= tableOffset {
+			return nil, fmt.Errorf("binary plist object %d out of range at %d", i, offset)
+		}
+		d.offsets[i] = int(offset)
+	}
+
+	return d.object(top, 0)
+}
+
+func (d *bplistDecoder) object(ref uint64, depth int) (interface{}, error) {
+	if ref >= uint64(len(d.offsets)) {
+		return nil, fmt.Errorf("binary plist reference %d out of range", ref)
+	}
+	if depth > bplistMaxDepth {
+		return nil, errors.New("binary plist nested too deeply")
+	}
+	if d.decoded++; d.decoded > bplistMaxObjects {
+		return nil, errors.New("binary plist has too many objects")
+	}
+
+	offset := d.offsets[ref]
+	marker := d.data[offset]
+	kind, info := marker>>4, int(marker&0x0f)
+
+	switch kind {
+	case 0x0:
+		switch info {
+		case 0x0:
+			return nil, nil
+		case 0x8:
+			return false, nil
+		case 0x9:
+			return true, nil
+		}
+	case 0x1:
+		// Integers of 1, 2 and 4 bytes are unsigned and 8 bytes signed. 16 byte integers are read from
+		// their low 8 bytes.
+		raw, err := d.bytes(offset+1, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		return int64(readUintBE(raw[max(0, len(raw)-8):])), nil
+	case 0x2:
+		raw, err := d.bytes(offset+1, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		switch len(raw) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+		}
+	case 0x3:
+		if info == 0x3 {
+			raw, err := d.bytes(offset+1, 8)
+			if err != nil {
+				return nil, err
+			}
+			seconds := math.Float64frombits(binary.BigEndian.Uint64(raw))
+			if math.IsNaN(seconds) || math.Abs(seconds) > 1<<40 {
+				return nil, fmt.Errorf("binary plist date out of range: %v", seconds)
+			}
+			return plistEpoch.Add(time.Duration(seconds * float64(time.Second))), nil
+		}
+	case 0x4, 0x5:
+		n, start, err := d.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.bytes(start, n)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x5 {
+			return string(raw), nil
+		}
+		return raw, nil
+	case 0x6:
+		n, start, err := d.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.bytes(start, n*2)
+		if err != nil {
+			return nil, err
+		}
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(raw[i*2:])
+		}
+		return string(utf16.Decode(units)), nil
+	case 0x8:
+		raw, err := d.bytes(offset+1, info+1)
+		if err != nil {
+			return nil, err
+		}
+		return readUintBE(raw[max(0, len(raw)-8):]), nil
+	case 0xa:
+		n, start, err := d.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(start, n)
+		if err != nil {
+			return nil, err
+		}
+		array := make([]interface{}, n)
+		for i, ref := range refs {
+			if array[i], err = d.object(ref, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	case 0xd:
+		n, start, err := d.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		// The references to every key come before those to the values
+		refs, err := d.refs(start, n*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			key, err := d.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("binary plist dictionary key is %T, not a string", key)
+			}
+			if dict[name], err = d.object(refs[n+i], depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+
+	return nil, fmt.Errorf("unsupported binary plist object 0x%02x at %d", marker, offset)
+}
+
+// length returns the element count of the object at offset and where its content starts. Counts of
+// 15 or more follow the marker as an integer object.
+func (d *bplistDecoder) length(offset, info int) (int, int, error) {
+	if info != 0xf {
+		return info, offset + 1, nil
+	}
+	marker, err := d.bytes(offset+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if marker[0]>>4 != 0x1 || marker[0]&0x0f > 3 {
+		return 0, 0, fmt.Errorf("invalid binary plist length marker 0x%02x", marker[0])
+	}
+	size := 1 << (marker[0] & 0x0f)
+	raw, err := d.bytes(offset+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	n := readUintBE(raw)
+	if n > uint64(d.end) {
+		return 0, 0, fmt.Errorf("binary plist length %d out of range", n)
+	}
+	return int(n), offset + 2 + size, nil
+}
+
+func (d *bplistDecoder) refs(start, n int) ([]uint64, error) {
+	raw, err := d.bytes(start, n*d.refSize)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		refs[i] = readUintBE(raw[i*d.refSize : (i+1)*d.refSize])
+	}
+	return refs, nil
+}
+
+func (d *bplistDecoder) bytes(start, n int) ([]byte, error) {
+	if n < 0 || start > d.end || n > d.end-start {
+		return nil, fmt.Errorf("binary plist object at %d runs past the object table", start)
+	}
+	return d.data[start : start+n], nil
+}
+
+// readUintBE reads a big-endian unsigned integer of up to 8 bytes
+func readUintBE(raw []byte) uint64 {
+	var v uint64
+	for _, b := range raw {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
@@ -0,0 +1,278 @@
+package helpers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// Severity How serious a parsing problem is
+type Severity string
+
+const (
+	// SeverityInfo The file is unusual but was read completely
+	SeverityInfo Severity = "info"
+	// SeverityWarning Part of the metadata was skipped or may be wrong
+	SeverityWarning Severity = "warning"
+	// SeverityError A whole structure could not be read
+	SeverityError Severity = "error"
+)
+
+// Diagnostic codes
+const (
+	DiagTruncated        = "truncated"
+	DiagOutOfRange       = "out_of_range"
+	DiagDecodeFailed     = "decode_failed"
+	DiagInvalidDate      = "invalid_date"
+	DiagInvalidValue     = "invalid_value"
+	DiagChecksumMismatch = "checksum_mismatch"
+	DiagLengthMismatch   = "length_mismatch"
+	DiagDateMismatch     = "date_mismatch"
+	DiagUnsupported      = "unsupported"
+	DiagIFDLoop          = "ifd_loop"
+	DiagOverlap          = "overlap"
+	DiagRelocated        = "relocated"
+)
+
+// maxRegions bounds the overlap tracker so a file with tens of thousands of entries stays linear
+const maxRegions = 4096
+
+// ErrIFDLoop is returned when an IFD pointer leads back to an IFD that was already read under another name
+var ErrIFDLoop = errors.New("IFD already visited")
+
+// Diagnostic A problem found while parsing. IFD, Tag and Offset are set when the problem can be
+// tied to a location; Offset is -1 otherwise.
+type Diagnostic struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	IFD      string   `json:"ifd,omitempty"`
+	Tag      Tag      `json:"tag,omitempty"`
+	Offset   int      `json:"offset"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	location := ""
+	if d.IFD != "" {
+		location = fmt.Sprintf(" %s", d.IFD)
+	}
+	if d.Tag != 0 {
+		location += fmt.Sprintf(" tag %#04x", uint16(d.Tag))
+	}
+	if d.Offset >= 0 {
+		location += fmt.Sprintf(" @%d", d.Offset)
+	}
+	return fmt.Sprintf("%s [%s]%s: %s", d.Severity, d.Code, location, d.Message)
+}
+
+// ParseContext State shared by every IFD walk of a single extraction. It travels on ValueExtractor so
+// that sub-IFD and MakerNote parsers can reach it. All methods accept a nil context, which logs to
+// the default logger and records nothing.
+type ParseContext struct {
+	// Logger receives debug output and every diagnostic, slog.Default() when nil
+	Logger      *slog.Logger
+	Diagnostics []Diagnostic
+
+	// RecordTags keeps every IFD entry seen, including unknown and private tags
+	RecordTags bool
+	Tags       []RawTag
+
+	// ifds maps the offset of every IFD read to its name, regions holds the IFD and out-of-line value
+	// byte ranges sorted by start
+	ifds    map[int]string
+	loops   map[string]bool
+	regions []region
+}
+
+// region A byte range claimed by an IFD or an out-of-line tag value. entry is the offset of the IFD
+// entry owning a value, or -1 for IFDs. maxEnd is the furthest end of this and every earlier region
+// in the sorted list.
+type region struct {
+	start, end int
+	name       string
+	entry      int
+	maxEnd     int
+}
+
+// Log returns the logger to write to
+func (c *ParseContext) Log() *slog.Logger {
+	if c == nil || c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+// Report records a diagnostic and logs it at the matching level. args are extra logger attributes
+// that are not kept on the diagnostic.
+func (c *ParseContext) Report(d Diagnostic, args ...any) {
+	level := slog.LevelWarn
+	switch d.Severity {
+	case SeverityInfo:
+		level = slog.LevelInfo
+	case SeverityError:
+		level = slog.LevelError
+	}
+
+	attrs := []any{"code", d.Code}
+	if d.IFD != "" {
+		attrs = append(attrs, "ifd", d.IFD)
+	}
+	if d.Tag != 0 {
+		attrs = append(attrs, "tag", fmt.Sprintf("%#x", uint16(d.Tag)))
+	}
+	if d.Offset >= 0 {
+		attrs = append(attrs, "offset", d.Offset)
+	}
+	c.Log().Log(context.Background(), level, d.Message, append(attrs, args...)...)
+
+	if c != nil {
+		c.Diagnostics = append(c.Diagnostics, d)
+	}
+}
+
+// Warn reports a warning that is not tied to a tag
+func (c *ParseContext) Warn(code, ifd string, offset int, message string, args ...any) {
+	c.Report(Diagnostic{Code: code, Severity: SeverityWarning, IFD: ifd, Offset: offset, Message: message}, args...)
+}
+
+// WarnTag reports a warning about a single IFD entry
+func (c *ParseContext) WarnTag(code, ifd string, entry IFDEntry, message string, args ...any) {
+	c.Report(Diagnostic{Code: code, Severity: SeverityWarning, IFD: ifd, Tag: entry.Tag, Offset: entry.Offset, Message: message}, args...)
+}
+
+// Error reports a structure that could not be read at all
+func (c *ParseContext) Error(code, ifd string, offset int, message string, args ...any) {
+	c.Report(Diagnostic{Code: code, Severity: SeverityError, IFD: ifd, Offset: offset, Message: message}, args...)
+}
+
+// IFDError reports an error from ReadIFD: a warning when some entries could still be read, an error
+// when the IFD was unreachable. Loops are reported by EnterIFD and ignored here.
+func (c *ParseContext) IFDError(ifd string, offset int, err error) {
+	if errors.Is(err, ErrIFDLoop) {
+		return
+	}
+	if errors.Is(err, ErrTruncated) {
+		c.Warn(DiagTruncated, ifd, offset, err.Error())
+		return
+	}
+	c.Error(DiagOutOfRange, ifd, offset, err.Error())
+}
+
+// EnterIFD marks the IFD at offset as read and claims its length bytes. Reading the same offset again
+// under the same name is allowed, as a TIFF walk revisits IFD0 and IFD1, but reaching it under another
+// name is a loop: it is reported and ErrIFDLoop returned so the caller stops.
+func (c *ParseContext) EnterIFD(name string, offset, length int) error {
+	if c == nil {
+		return nil
+	}
+	if c.ifds == nil {
+		c.ifds = map[int]string{}
+	}
+
+	if previous, ok := c.ifds[offset]; ok {
+		if previous == name {
+			return nil
+		}
+		// A TIFF walk follows the same pointers as the EXIF pass, so report each loop once
+		if key := fmt.Sprintf("%s@%d", name, offset); !c.loops[key] {
+			if c.loops == nil {
+				c.loops = map[string]bool{}
+			}
+			c.loops[key] = true
+			c.Warn(DiagIFDLoop, name, offset, fmt.Sprintf("%s points back at %s", name, previous))
+		}
+		return ErrIFDLoop
+	}
+	c.ifds[offset] = name
+
+	c.claim(region{start: offset, end: offset + length, name: name, entry: -1})
+	return nil
+}
+
+// ClaimValue claims the bytes of an out-of-line tag value, reporting any overlap with an IFD or another
+// value. Values of 4 bytes or fewer live inside their IFD entry and are skipped.
+func (c *ParseContext) ClaimValue(ifd string, entry IFDEntry, value TagValue) {
+	if c == nil || len(value.Raw) <= 4 {
+		return
+	}
+	c.claim(region{
+		start: value.Offset,
+		end:   value.Offset + len(value.Raw),
+		name:  fmt.Sprintf("%s tag %#04x", ifd, uint16(entry.Tag)),
+		entry: entry.Offset,
+	})
+}
+
+// claim inserts r into the sorted region list and reports the first overlap found
+func (c *ParseContext) claim(r region) {
+	i, _ := slices.BinarySearchFunc(c.regions, r.start, func(existing region, start int) int {
+		return cmp.Compare(existing.start, start)
+	})
+
+	for j := i; j < len(c.regions) && c.regions[j].start == r.start; j++ {
+		if other := c.regions[j]; other.end == r.end && other.entry == r.entry {
+			// The same structure read twice, such as IFD0 in a TIFF walk
+			return
+		}
+	}
+
+	// Regions from i on start at or after r, so the first of them is the only one that needs checking.
+	// Those before it start earlier and overlap when they end after r starts, which maxEnd rules out
+	// for the rest of the list once no earlier region reaches that far.
+	overlap := -1
+	if i < len(c.regions) && c.regions[i].start < r.end {
+		overlap = i
+	}
+	for j := i - 1; overlap == -1 && j >= 0 && c.regions[j].maxEnd > r.start; j-- {
+		if c.regions[j].end > r.start {
+			overlap = j
+		}
+	}
+	if overlap != -1 {
+		other := c.regions[overlap]
+		c.Warn(DiagOverlap, r.name, r.start, fmt.Sprintf("%s [%d, %d) overlaps %s [%d, %d)", r.name, r.start, r.end, other.name, other.start, other.end))
+	}
+
+	if len(c.regions) < maxRegions {
+		c.regions = slices.Insert(c.regions, i, r)
+		for j := i; j < len(c.regions); j++ {
+			c.regions[j].maxEnd = c.regions[j].end
+			if j > 0 {
+				c.regions[j].maxEnd = max(c.regions[j].maxEnd, c.regions[j-1].maxEnd)
+			}
+		}
+	}
+}
+
+// RecordTag stores an IFD entry and its decoded value when tag recording is enabled. Entries whose
+// value could not be decoded are kept with the error rather than dropped.
+func (c *ParseContext) RecordTag(ifd string, entry IFDEntry, value TagValue, err error) {
+	if c == nil || !c.RecordTags {
+		return
+	}
+
+	tag := RawTag{
+		IFD:         ifd,
+		Tag:         entry.Tag,
+		DataType:    entry.DataType,
+		Type:        TypeName(entry.DataType),
+		Count:       entry.Count,
+		Offset:      entry.Offset,
+		ValueOffset: value.Offset,
+		Raw:         value.Raw,
+		Value:       value.Value,
+	}
+	if err != nil {
+		tag.Error = err.Error()
+	}
+
+	c.Tags = append(c.Tags, tag)
+}
+
+// String formats the decoded value the same way as TagValue.String
+func (t RawTag) String() string {
+	return TagValue{Type: t.DataType, Count: t.Count, Raw: t.Raw, Value: t.Value}.String()
+}
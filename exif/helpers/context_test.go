@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestClaimOverlap(t *testing.T) {
+	type claim struct {
+		name       string
+		start, end int
+	}
+	tests := []struct {
+		name   string
+		claims []claim
+		want   int
+	}{
+		{"disjoint", []claim{{"IFD0", 100, 200}, {"ExifIFD", 200, 300}, {"GPS", 400, 500}}, 0},
+		{"same IFD twice", []claim{{"IFD0", 100, 200}, {"IFD0", 100, 200}}, 0},
+		{"neighbours", []claim{{"IFD0", 100, 200}, {"ExifIFD", 150, 250}}, 1},
+		// The last region only overlaps the outer one, which is not its neighbour in the sorted list
+		{"long outer region", []claim{{"MakerNoteIFD", 100, 5000}, {"ExifIFD", 150, 160}, {"GPS", 200, 300}}, 2},
+		{"claimed before the outer region", []claim{{"ExifIFD", 150, 160}, {"GPS", 200, 300}, {"MakerNoteIFD", 100, 5000}}, 1},
+		{"past the outer region", []claim{{"MakerNoteIFD", 100, 5000}, {"ExifIFD", 150, 160}, {"GPS", 5000, 5100}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &ParseContext{Logger: slog.New(slog.DiscardHandler)}
+			for _, c := range tt.claims {
+				if err := ctx.EnterIFD(c.name, c.start, c.end-c.start); err != nil {
+					t.Fatalf("EnterIFD(%s) error = %v", c.name, err)
+				}
+			}
+
+			overlaps := 0
+			for _, d := range ctx.Diagnostics {
+				if d.Code == DiagOverlap {
+					overlaps++
+				}
+			}
+			if overlaps != tt.want {
+				t.Errorf("Diagnostics = %v, want %d overlaps", ctx.Diagnostics, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 )
@@ -11,9 +12,55 @@ import (
 type Tag uint16
 
 type MakerNoteData struct {
-	Raw          []byte                 `json:"raw"`
-	Manufacturer string                 `json:"manufacturer"`
-	Parsed       map[string]interface{} `json:"parsed"`
+	Raw          []byte `json:"raw"`
+	Manufacturer string `json:"manufacturer"`
+	MatchedBy    string `json:"matchedBy,omitempty"`
+	// Parsed is a vendor struct, such as makernotes.AppleMakerNote, for vendors with a typed
+	// representation and a GenericMakerNote for the rest
+	Parsed MakerNoteFields `json:"parsed"`
+}
+
+// MakerNoteFields The decoded content of a MakerNote
+type MakerNoteFields interface {
+	// Fields returns the decoded values keyed by their JSON names
+	Fields() map[string]interface{}
+}
+
+// GenericMakerNote Decoded MakerNote values for vendors without a typed representation
+type GenericMakerNote map[string]interface{}
+
+func (g GenericMakerNote) Fields() map[string]interface{} {
+	return g
+}
+
+// StructFields returns the exported fields of a struct, or pointer to one, keyed by their JSON names,
+// so typed MakerNotes can provide the same view as a GenericMakerNote
+func StructFields(v interface{}) map[string]interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields := make(map[string]interface{})
+	if rv.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omit := strings.Contains(options, "omitempty") || strings.Contains(options, "omitzero")
+		if omit && rv.Field(i).IsZero() {
+			continue
+		}
+		fields[name] = rv.Field(i).Interface()
+	}
+	return fields
 }
 
 type GPSExif struct {
@@ -61,17 +108,29 @@ type DeviceData struct {
 
 // ImageProperties Image dimensions and properties
 type ImageProperties struct {
-	Width            int     `json:"width"`
-	Height           int     `json:"height"`
-	PixelXDimension  float64 `json:"pixelXDimension"`
-	PixelYDimension  float64 `json:"pixelYDimension"`
-	Orientation      string  `json:"orientation"`
-	ColorSpace       string  `json:"colorSpace"`
-	ComponentsConfig string  `json:"componentsConfiguration"`
-	FileSource       string  `json:"fileSource"`
-	SceneType        string  `json:"sceneType"`
-	ExifVersion      string  `json:"exifVersion"`
-	FlashpixVersion  string  `json:"flashpixVersion"`
+	Width              int     `json:"width"`
+	Height             int     `json:"height"`
+	PixelXDimension    float64 `json:"pixelXDimension"`
+	PixelYDimension    float64 `json:"pixelYDimension"`
+	Orientation        string  `json:"orientation"`
+	ColorSpace         string  `json:"colorSpace"`
+	ComponentsConfig   string  `json:"componentsConfiguration"`
+	FileSource         string  `json:"fileSource"`
+	SceneType          string  `json:"sceneType"`
+	ExifVersion        string  `json:"exifVersion"`
+	FlashpixVersion    string  `json:"flashpixVersion"`
+	CanvasWidth        int     `json:"canvasWidth"`
+	CanvasHeight       int     `json:"canvasHeight"`
+	Animated           bool    `json:"animated"`
+	HasAlpha           bool    `json:"hasAlpha"`
+	AnimationFrames    int     `json:"animationFrames"`
+	AnimationLoopCount int     `json:"animationLoopCount"`
+	// InteropIndex distinguishes DCF basic (sRGB) files from option (Adobe RGB) files
+	InteropIndex           string `json:"interopIndex"`
+	InteropVersion         string `json:"interopVersion"`
+	RelatedImageFileFormat string `json:"relatedImageFileFormat"`
+	RelatedImageWidth      int    `json:"relatedImageWidth"`
+	RelatedImageHeight     int    `json:"relatedImageHeight"`
 }
 
 // CameraSettings Camera settings used during capture
@@ -117,20 +176,137 @@ type AuthorshipData struct {
 
 // AuthenticityData Authenticity and integrity markers
 type AuthenticityData struct {
-	ImageUniqueID    string        `json:"imageUniqueID"`
-	MakerNote        MakerNoteData `json:"makerNote"`
-	RelatedSoundFile string        `json:"relatedSoundFile"`
+	ImageUniqueID    string             `json:"imageUniqueID"`
+	MakerNote        MakerNoteData      `json:"makerNote"`
+	RelatedSoundFile string             `json:"relatedSoundFile"`
+	SamsungTrailer   SamsungTrailerData `json:"samsungTrailer"`
+}
+
+// SamsungTrailerEntry One block of a Samsung trailer. Offset and Length locate the block's data in the
+// file, after its name, so embedded media can be cut out directly.
+type SamsungTrailerEntry struct {
+	Type   uint16 `json:"type"`
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	// MediaType is set for embedded files such as motion photo video and depth maps
+	MediaType string `json:"mediaType,omitempty"`
+	// Value holds the block's data when it is short text
+	Value string `json:"value,omitempty"`
+}
+
+// SamsungTrailerData The SEFH directory Samsung cameras append after the end of the JPEG image data
+type SamsungTrailerData struct {
+	Offset                int                   `json:"offset"`
+	Version               int                   `json:"version"`
+	Entries               []SamsungTrailerEntry `json:"entries"`
+	CaptureTime           time.Time             `json:"captureTime"`
+	MCC                   string                `json:"mcc"`
+	CameraCaptureModeInfo string                `json:"cameraCaptureModeInfo"`
+	DualShotInfo          string                `json:"dualShotInfo"`
+}
+
+// DroneData Aircraft and gimbal attitude recorded by drones, from which the camera pose can be
+// reconstructed. Angles are in degrees, altitudes in metres and speeds in metres per second.
+type DroneData struct {
+	AbsoluteAltitude float64 `json:"absoluteAltitude"`
+	// RelativeAltitude is the height above the take-off point
+	RelativeAltitude float64 `json:"relativeAltitude"`
+	GimbalYaw        float64 `json:"gimbalYaw"`
+	GimbalPitch      float64 `json:"gimbalPitch"`
+	GimbalRoll       float64 `json:"gimbalRoll"`
+	FlightYaw        float64 `json:"flightYaw"`
+	FlightPitch      float64 `json:"flightPitch"`
+	FlightRoll       float64 `json:"flightRoll"`
+	SpeedX           float64 `json:"speedX"`
+	SpeedY           float64 `json:"speedY"`
+	SpeedZ           float64 `json:"speedZ"`
+	// RtkFlag is the RTK positioning status: 0 none, 16 single point, 34 float and 50 fixed
+	RtkFlag               int     `json:"rtkFlag"`
+	CalibratedFocalLength float64 `json:"calibratedFocalLength"`
+}
+
+// TIFFImage An image described by one IFD of a standalone TIFF or DNG file
+type TIFFImage struct {
+	IFD                       string   `json:"ifd"`
+	Offset                    int      `json:"offset"`
+	SubfileType               string   `json:"subfileType"`
+	Width                     int      `json:"width"`
+	Height                    int      `json:"height"`
+	BitsPerSample             []uint32 `json:"bitsPerSample"`
+	SamplesPerPixel           int      `json:"samplesPerPixel"`
+	Compression               string   `json:"compression"`
+	PhotometricInterpretation string   `json:"photometricInterpretation"`
+}
+
+// DNGData Adobe Digital Negative colour and camera identification tags
+type DNGData struct {
+	DNGVersion             string    `json:"dngVersion"`
+	DNGBackwardVersion     string    `json:"dngBackwardVersion"`
+	UniqueCameraModel      string    `json:"uniqueCameraModel"`
+	LocalizedCameraModel   string    `json:"localizedCameraModel"`
+	ColorMatrix1           []float64 `json:"colorMatrix1"`
+	ColorMatrix2           []float64 `json:"colorMatrix2"`
+	CameraCalibration1     []float64 `json:"cameraCalibration1"`
+	CameraCalibration2     []float64 `json:"cameraCalibration2"`
+	AsShotNeutral          []float64 `json:"asShotNeutral"`
+	CalibrationIlluminant1 string    `json:"calibrationIlluminant1"`
+	CalibrationIlluminant2 string    `json:"calibrationIlluminant2"`
+	BaselineExposure       float64   `json:"baselineExposure"`
+}
+
+// TIFFData IFD layout of standalone TIFF and DNG files
+type TIFFData struct {
+	Images []TIFFImage `json:"images"`
+	DNG    DNGData     `json:"dng"`
+}
+
+// ThumbnailData Embedded preview image described by IFD1
+type ThumbnailData struct {
+	Offset         int     `json:"offset"`
+	Length         int     `json:"length"`
+	Compression    string  `json:"compression"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	XResolution    float64 `json:"xResolution"`
+	YResolution    float64 `json:"yResolution"`
+	ResolutionUnit string  `json:"resolutionUnit"`
+	SHA256         string  `json:"sha256"`
+	Data           []byte  `json:"data"`
+}
+
+// RawTag A single IFD entry preserved verbatim. Offset is the absolute position of the 12 byte entry
+// and ValueOffset that of its value bytes, both in the file unless the container stored EXIF
+// compressed or in pieces, when they are positions in the reassembled EXIF block.
+type RawTag struct {
+	IFD         string      `json:"ifd"`
+	Tag         Tag         `json:"tag"`
+	DataType    uint16      `json:"dataType"`
+	Type        string      `json:"type"`
+	Count       uint32      `json:"count"`
+	Offset      int         `json:"offset"`
+	ValueOffset int         `json:"valueOffset"`
+	Raw         []byte      `json:"raw"`
+	Value       interface{} `json:"value"`
+	Error       string      `json:"error,omitempty"`
 }
 
 type PhotoExifEvidence struct {
-	Temporal     TemporalData     `json:"temporal"`
-	GPS          GPSExif          `json:"gps"`
-	Device       DeviceData       `json:"device"`
-	Image        ImageProperties  `json:"image"`
-	Camera       CameraSettings   `json:"camera"`
-	Processing   ProcessingData   `json:"processing"`
-	Authorship   AuthorshipData   `json:"authorship"`
-	Authenticity AuthenticityData `json:"authenticity"`
+	Temporal     TemporalData      `json:"temporal"`
+	GPS          GPSExif           `json:"gps"`
+	Device       DeviceData        `json:"device"`
+	Image        ImageProperties   `json:"image"`
+	Camera       CameraSettings    `json:"camera"`
+	Processing   ProcessingData    `json:"processing"`
+	Authorship   AuthorshipData    `json:"authorship"`
+	Authenticity AuthenticityData  `json:"authenticity"`
+	Thumbnail    ThumbnailData     `json:"thumbnail"`
+	TIFF         TIFFData          `json:"tiff"`
+	Drone        DroneData         `json:"drone"`
+	XMP          string            `json:"xmp"`
+	TextChunks   map[string]string `json:"textChunks"`
+	Tags         []RawTag          `json:"tags,omitempty"`
+	Warnings     []Diagnostic      `json:"warnings"`
 }
 
 type IFDEntry struct {
@@ -138,26 +314,109 @@ type IFDEntry struct {
 	DataType    uint16
 	Count       uint32
 	ValueOffset uint32
+	// Offset is the position of the 12 byte entry itself, where inline values are read from
+	Offset int
+}
+
+// IsTIFF checks for a TIFF byte order mark and magic number at the start of data
+func IsTIFF(data []byte) bool {
+	return len(data) >= 4 && (string(data[0:4]) == "II*\x00" || string(data[0:4]) == "MM\x00*")
+}
+
+// ParseTIFFHeader reads the byte order mark, magic number and first IFD offset of a TIFF header
+// starting at tiffStart
+func ParseTIFFHeader(src *Source, tiffStart int) (binary.ByteOrder, uint32, error) {
+	header, err := src.Slice(tiffStart, 8)
+	if errors.Is(err, ErrTruncated) {
+		return nil, 0, errors.New("TIFF header out of range")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var endian binary.ByteOrder
+	switch string(header[0:2]) {
+	case "II":
+		endian = binary.LittleEndian
+	case "MM":
+		endian = binary.BigEndian
+	default:
+		return nil, 0, errors.New("unsupported byte order")
+	}
+
+	if magic := endian.Uint16(header[2:4]); magic != 42 {
+		return nil, 0, fmt.Errorf("invalid TIFF magic number %d", magic)
+	}
+
+	return endian, endian.Uint32(header[4:8]), nil
 }
 
-func DetermineEndianess(data []byte, offset int) (binary.ByteOrder, error) {
-	if data[offset+10] == 0x49 && data[offset+11] == 0x49 {
-		return binary.LittleEndian, nil
-	} else if data[offset+10] == 0x4D && data[offset+11] == 0x4D {
-		return binary.BigEndian, nil
+// ErrTruncated is wrapped by errors for structures that run past the end of the data
+var ErrTruncated = errors.New("data truncated")
+
+// ParseIFDEntry reads the 12 byte IFD entry at offset
+func ParseIFDEntry(src *Source, offset int, endian binary.ByteOrder) (IFDEntry, error) {
+	raw, err := src.Slice(offset, 12)
+	if err != nil {
+		return IFDEntry{}, fmt.Errorf("IFD entry at offset %d: %w", offset, err)
 	}
-	return nil, errors.New("unsupported byte order")
+	return decodeIFDEntry(raw, offset, endian), nil
 }
 
-func ParseIFDEntry(data []byte, offset int, endian binary.ByteOrder) IFDEntry {
+func decodeIFDEntry(raw []byte, offset int, endian binary.ByteOrder) IFDEntry {
 	return IFDEntry{
-		Tag:         Tag(endian.Uint16(data[offset : offset+2])),
-		DataType:    endian.Uint16(data[offset+2 : offset+4]),
-		Count:       endian.Uint32(data[offset+4 : offset+8]),
-		ValueOffset: endian.Uint32(data[offset+8 : offset+12]),
+		Tag:         Tag(endian.Uint16(raw[0:2])),
+		DataType:    endian.Uint16(raw[2:4]),
+		Count:       endian.Uint32(raw[4:8]),
+		ValueOffset: endian.Uint32(raw[8:12]),
+		Offset:      offset,
 	}
 }
 
+// IFD An image file directory: its entries and the pointer to the next IFD in the chain, which is
+// relative to the TIFF header like every other IFD offset
+type IFD struct {
+	Offset  int
+	Entries []IFDEntry
+	Next    uint32
+}
+
+// ReadIFD reads the entry count, entries and next IFD pointer of the IFD at offset. When the entries
+// run past the end of the file the ones that fit are returned along with an error wrapping ErrTruncated.
+func ReadIFD(src *Source, offset int, endian binary.ByteOrder) (IFD, error) {
+	ifd := IFD{Offset: offset}
+	raw, err := src.Slice(offset, 2)
+	if errors.Is(err, ErrTruncated) {
+		return ifd, fmt.Errorf("IFD offset %d out of range", offset)
+	}
+	if err != nil {
+		return ifd, err
+	}
+
+	// The entries are read in one go, up to as many as the file can hold
+	count := int(endian.Uint16(raw))
+	fit := min(count, (src.Size()-offset-2)/12)
+	raw, err = src.Slice(offset+2, fit*12)
+	if err != nil {
+		return ifd, err
+	}
+
+	ifd.Entries = make([]IFDEntry, fit)
+	for j := range ifd.Entries {
+		ifd.Entries[j] = decodeIFDEntry(raw[j*12:j*12+12], offset+2+j*12, endian)
+	}
+	if fit < count {
+		return ifd, fmt.Errorf("IFD at offset %d declares %d entries, only %d fit: %w", offset, count, fit, ErrTruncated)
+	}
+
+	// A missing next pointer is common in hand-written files and treated as the end of the chain
+	if next, err := src.Slice(offset+2+count*12, 4); err == nil {
+		ifd.Next = endian.Uint32(next)
+	}
+
+	return ifd, nil
+}
+
 func ParseOrientationValue(raw uint16) string {
 	switch raw {
 	case 1:
@@ -477,3 +736,146 @@ func ParseCompositeImage(raw uint16) string {
 		return "Not defined"
 	}
 }
+
+func ParseWhiteBalance(raw uint16) string {
+	switch raw {
+	case 0:
+		return "Auto"
+	case 1:
+		return "Manual"
+	default:
+		return "Unknown"
+	}
+}
+
+// FormatLensInfo formats the min/max focal length and min/max aperture of LensInfo, e.g. "24-70mm f/2.8"
+func FormatLensInfo(values []float64) string {
+	if len(values) != 4 {
+		return ""
+	}
+
+	focal := fmt.Sprintf("%gmm", values[0])
+	if values[1] != values[0] {
+		focal = fmt.Sprintf("%g-%gmm", values[0], values[1])
+	}
+
+	aperture := fmt.Sprintf("f/%g", values[2])
+	if values[3] != values[2] && values[3] != 0 {
+		aperture = fmt.Sprintf("f/%g-%g", values[2], values[3])
+	}
+
+	return focal + " " + aperture
+}
+
+func ParseInteropIndex(raw string) string {
+	switch raw {
+	case "R98":
+		return "R98 - DCF basic file (sRGB)"
+	case "R03":
+		return "R03 - DCF option file (Adobe RGB)"
+	case "THM":
+		return "THM - DCF thumbnail file"
+	default:
+		return raw
+	}
+}
+
+func ParseResolutionUnit(raw uint16) string {
+	switch raw {
+	case 1:
+		return "None"
+	case 2:
+		return "inches"
+	case 3:
+		return "cm"
+	default:
+		return "Unknown"
+	}
+}
+
+func ParseSubfileType(raw uint32) string {
+	switch raw {
+	case 0x0:
+		return "Full-resolution image"
+	case 0x1:
+		return "Reduced-resolution image"
+	case 0x2:
+		return "Single page of multi-page image"
+	case 0x3:
+		return "Single page of multi-page reduced-resolution image"
+	case 0x4:
+		return "Transparency mask"
+	case 0x5:
+		return "Transparency mask of reduced-resolution image"
+	case 0x6:
+		return "Transparency mask of multi-page image"
+	case 0x7:
+		return "Transparency mask of reduced-resolution multi-page image"
+	case 0x8:
+		return "Depth map"
+	case 0x10:
+		return "Enhanced image data"
+	case 0x10001:
+		return "Alternate reduced-resolution image"
+	default:
+		return "Unknown"
+	}
+}
+
+func ParseCompression(raw uint32) string {
+	switch raw {
+	case 1:
+		return "Uncompressed"
+	case 2:
+		return "CCITT 1D"
+	case 3:
+		return "T4/Group 3 Fax"
+	case 4:
+		return "T6/Group 4 Fax"
+	case 5:
+		return "LZW"
+	case 6:
+		return "JPEG (old-style)"
+	case 7:
+		return "JPEG"
+	case 8:
+		return "Adobe Deflate"
+	case 32773:
+		return "PackBits"
+	case 34713:
+		return "Nikon NEF Compressed"
+	case 34892:
+		return "Lossy JPEG"
+	case 52546:
+		return "JPEG XL"
+	default:
+		return "Unknown"
+	}
+}
+
+func ParsePhotometricInterpretation(raw uint32) string {
+	switch raw {
+	case 0:
+		return "WhiteIsZero"
+	case 1:
+		return "BlackIsZero"
+	case 2:
+		return "RGB"
+	case 3:
+		return "RGB Palette"
+	case 4:
+		return "Transparency Mask"
+	case 5:
+		return "CMYK"
+	case 6:
+		return "YCbCr"
+	case 8:
+		return "CIELab"
+	case 32803:
+		return "Color Filter Array"
+	case 34892:
+		return "Linear Raw"
+	default:
+		return "Unknown"
+	}
+}
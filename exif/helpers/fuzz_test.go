@@ -0,0 +1,125 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// exampleHead returns the APP segments of example.jpg, which carry XMP and a three-part extended XMP packet
+func exampleHead(f *testing.F) []byte {
+	data, err := os.ReadFile(filepath.Join("..", "..", "example.jpg"))
+	if err != nil {
+		f.Skip("example.jpg not available")
+	}
+	return data[:98<<10]
+}
+
+// xmpJPEG wraps XMP and extended XMP APP1 segments in an otherwise empty JPEG
+func xmpJPEG(segments ...string) []byte {
+	out := []byte{0xff, 0xd8}
+	for _, segment := range segments {
+		out = append(out, 0xff, MarkerAPP1, byte((len(segment)+2)>>8), byte(len(segment)+2))
+		out = append(out, segment...)
+	}
+	return append(out, 0xff, 0xd9)
+}
+
+const fuzzGUID = "0123456789ABCDEF0123456789ABCDEF"
+
+func FuzzExtractXMPData(f *testing.F) {
+	f.Add(exampleHead(f))
+	f.Add(xmpJPEG(XMPSignature + `<x:xmpmeta xmlns:x="adobe:ns:meta/"></x:xmpmeta>`))
+	f.Add(xmpJPEG(XMPSignature + `<x:xmpmeta`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		packet, err := ExtractXMPData(data)
+		if err == nil && !strings.HasSuffix(packet, "</x:xmpmeta>") {
+			t.Fatalf("packet does not end at the closing xmpmeta tag: %q", packet)
+		}
+	})
+}
+
+func FuzzExtractExtXMPData(f *testing.F) {
+	head := exampleHead(f)
+	packet, _ := ExtractXMPData(head)
+	xmp, _ := DecodeXMPMeta([]byte(packet))
+	f.Add(head, xmp.RDF.Description.HasExtendedXMP)
+
+	ext := `<x:xmpmeta xmlns:x="adobe:ns:meta/"></x:xmpmeta>`
+	chunk := func(offset int, data string) string {
+		return ExtXMPSignature + fuzzGUID + string([]byte{0, 0, 0, byte(len(ext)), 0, 0, 0, byte(offset)}) + data
+	}
+	f.Add(xmpJPEG(chunk(20, ext[20:]), chunk(0, ext[:20])), fuzzGUID)
+	f.Add(xmpJPEG(chunk(200, ext)), fuzzGUID)
+
+	f.Fuzz(func(t *testing.T, data []byte, guid string) {
+		if packet, err := ExtractExtXMPData(data, guid); err == nil && len(packet) > len(data) {
+			t.Fatalf("reassembled %d bytes from a %d byte file", len(packet), len(data))
+		}
+	})
+}
+
+func FuzzSanitizeBase64String(f *testing.F) {
+	f.Add("SERSUAE=")
+	f.Add("SERS\r\nUAE")
+	f.Add("\x00\x08 abc==de=")
+	f.Add("é")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		cleaned := SanitizeBase64String(s)
+		if len(cleaned)%4 != 0 {
+			t.Fatalf("length %d is not a multiple of 4: %q", len(cleaned), cleaned)
+		}
+		if i := strings.IndexFunc(cleaned, func(r rune) bool {
+			return !strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=", r)
+		}); i != -1 {
+			t.Fatalf("invalid character at %d: %q", i, cleaned)
+		}
+	})
+}
+
+// binaryPlist lays out encoded objects with one byte offsets and references. The first object is
+// the top one.
+func binaryPlist(objects ...[]byte) []byte {
+	out := []byte(BinaryPlistSignature)
+	var offsets []byte
+	for _, object := range objects {
+		offsets = append(offsets, byte(len(out)))
+		out = append(out, object...)
+	}
+	table := len(out)
+	out = append(out, offsets...)
+
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(objects)))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(table))
+	return append(out, trailer...)
+}
+
+func FuzzDecodeBinaryPlist(f *testing.F) {
+	// A dictionary of an integer, a real, a date, UTF-16 text, data and a boolean
+	f.Add(binaryPlist(
+		[]byte{0xd6, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+		[]byte("\x51a"), []byte("\x51b"), []byte("\x51c"), []byte("\x51d"), []byte("\x51e"), []byte("\x51f"),
+		[]byte{0x12, 0, 0, 1, 0},
+		[]byte{0x23, 0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18},
+		[]byte{0x33, 0x41, 0xc0, 0, 0, 0, 0, 0, 0},
+		[]byte{0x62, 0, 'h', 0, 'i'},
+		[]byte{0x43, 1, 2, 3},
+		[]byte{0x09},
+	))
+	// A string whose length follows as an integer, and an array containing itself
+	f.Add(binaryPlist([]byte("\x5f\x10\x10sixteen bytes..."), []byte{0xa1, 0}))
+	f.Add(binaryPlist([]byte{0xa1, 0}))
+	f.Add([]byte(BinaryPlistSignature))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if value, err := DecodeBinaryPlist(data); err != nil && value != nil {
+			t.Fatalf("DecodeBinaryPlist() returned %v with error %v", value, err)
+		}
+	})
+}
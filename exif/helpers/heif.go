@@ -0,0 +1,482 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// HEIF item location construction methods (ISO/IEC 14496-12 iloc box)
+const (
+	ConstructionFileOffset = 0
+	ConstructionIdatOffset = 1
+	ConstructionItemOffset = 2
+)
+
+// HEIFExtent A contiguous run of bytes making up part of an item
+type HEIFExtent struct {
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+}
+
+// HEIFItem An item declared in the iinf box, with its location from iloc and references from iref
+type HEIFItem struct {
+	ID                 uint32              `json:"id"`
+	Type               string              `json:"type"`
+	Name               string              `json:"name"`
+	ContentType        string              `json:"contentType"`
+	ConstructionMethod uint8               `json:"constructionMethod"`
+	Extents            []HEIFExtent        `json:"extents"`
+	References         map[string][]uint32 `json:"references"`
+}
+
+// HEIFFile The parts of an ISO-BMFF (HEIC/HEIF/AVIF) file needed to locate metadata items
+type HEIFFile struct {
+	MajorBrand       string     `json:"majorBrand"`
+	CompatibleBrands []string   `json:"compatibleBrands"`
+	PrimaryItemID    uint32     `json:"primaryItemID"`
+	Items            []HEIFItem `json:"items"`
+
+	idat []byte
+}
+
+// heifBox A box header and the bounds of its payload within the source it was read from
+type heifBox struct {
+	Type  string
+	Start int
+	End   int
+}
+
+var heifBrands = []string{"heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1", "avif", "avis"}
+
+// IsHEIF checks for an ftyp box naming one of the HEIF brands
+func IsHEIF(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	size := int(binary.BigEndian.Uint32(data[0:4]))
+	if size < 16 || size > len(data) {
+		size = len(data)
+	}
+
+	for _, brand := range heifBrands {
+		if string(data[8:12]) == brand {
+			return true
+		}
+		// Compatible brands follow the major brand and minor version
+		for i := 16; i+4 <= size; i += 4 {
+			if string(data[i:i+4]) == brand {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readBoxes splits the range [start, end) of src into a list of sibling boxes, reading only their headers
+func readBoxes(src *Source, start, end int) ([]heifBox, error) {
+	var boxes []heifBox
+	pos := start
+	for pos+8 <= end {
+		header, err := src.Slice(pos, min(16, end-pos))
+		if err != nil {
+			return boxes, err
+		}
+		size := uint64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+		headerSize := 8
+
+		switch size {
+		case 0:
+			// Box extends to the end of its parent
+			size = uint64(end - pos)
+		case 1:
+			if len(header) < 16 {
+				return boxes, fmt.Errorf("truncated large size for box %q at offset %d", boxType, pos)
+			}
+			size = binary.BigEndian.Uint64(header[8:16])
+			headerSize = 16
+		}
+
+		if size < uint64(headerSize) || size > uint64(end-pos) {
+			return boxes, fmt.Errorf("invalid size %d for box %q at offset %d", size, boxType, pos)
+		}
+
+		boxes = append(boxes, heifBox{Type: boxType, Start: pos + headerSize, End: pos + int(size)})
+		pos += int(size)
+	}
+	return boxes, nil
+}
+
+// heifReader reads big-endian fields from a box payload, remembering the first out-of-range read
+type heifReader struct {
+	data []byte
+	pos  int
+	end  int
+	err  error
+}
+
+func (r *heifReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > r.end {
+		r.err = fmt.Errorf("box truncated at offset %d", r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *heifReader) uint8() uint8 {
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *heifReader) uint16() uint16 {
+	if b := r.take(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *heifReader) uint32() uint32 {
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// uintN reads an unsigned integer of 0, 4 or 8 bytes as used by iloc's variable-sized fields
+func (r *heifReader) uintN(size int) uint64 {
+	switch size {
+	case 0:
+		return 0
+	case 4:
+		return uint64(r.uint32())
+	case 8:
+		if b := r.take(8); b != nil {
+			return binary.BigEndian.Uint64(b)
+		}
+		return 0
+	default:
+		r.err = fmt.Errorf("unsupported iloc field size %d", size)
+		return 0
+	}
+}
+
+func (r *heifReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	end := bytes.IndexByte(r.data[r.pos:r.end], 0)
+	if end == -1 {
+		s := string(r.data[r.pos:r.end])
+		r.pos = r.end
+		return s
+	}
+	s := string(r.data[r.pos : r.pos+end])
+	r.pos += end + 1
+	return s
+}
+
+// fullBoxHeader reads the version and flags of a FullBox
+func (r *heifReader) fullBoxHeader() (uint8, uint32) {
+	v := r.uint32()
+	return uint8(v >> 24), v & 0xffffff
+}
+
+// ReadHEIF parses the ftyp and meta boxes of an ISO-BMFF file, returning every item along with
+// where its bytes live
+func ReadHEIF(src *Source) (*HEIFFile, error) {
+	if head, err := src.Slice(0, min(src.Size(), sourceReadAhead)); err != nil || !IsHEIF(head) {
+		return nil, errors.New("file is not a HEIF container")
+	}
+
+	topLevel, err := readBoxes(src, 0, src.Size())
+	if len(topLevel) == 0 {
+		return nil, err
+	}
+
+	file := &HEIFFile{}
+	var meta *heifBox
+	for i, box := range topLevel {
+		switch box.Type {
+		case "ftyp":
+			payload, err := src.Slice(box.Start, box.End-box.Start)
+			if err != nil {
+				return nil, err
+			}
+			r := &heifReader{data: payload, end: len(payload)}
+			file.MajorBrand = string(r.take(4))
+			r.uint32() // minor version
+			for r.pos+4 <= r.end {
+				file.CompatibleBrands = append(file.CompatibleBrands, string(r.take(4)))
+			}
+		case "meta":
+			meta = &topLevel[i]
+		}
+	}
+
+	if meta == nil {
+		return nil, errors.New("HEIF file has no meta box")
+	}
+
+	// The meta box is small next to the media data and is read whole. Its children's bounds are
+	// relative to it, and as a FullBox they start after the version and flags.
+	data, err := src.Slice(meta.Start, meta.End-meta.Start)
+	if err != nil {
+		return nil, err
+	}
+	children, err := readBoxes(NewBytesSource(data), 4, len(data))
+	if len(children) == 0 {
+		return nil, fmt.Errorf("HEIF meta box is empty: %w", err)
+	}
+
+	items := make(map[uint32]*HEIFItem)
+	var order []uint32
+	getItem := func(id uint32) *HEIFItem {
+		item, ok := items[id]
+		if !ok {
+			item = &HEIFItem{ID: id, References: map[string][]uint32{}}
+			items[id] = item
+			order = append(order, id)
+		}
+		return item
+	}
+
+	for _, box := range children {
+		r := &heifReader{data: data, pos: box.Start, end: box.End}
+		switch box.Type {
+		case "pitm":
+			version, _ := r.fullBoxHeader()
+			if version == 0 {
+				file.PrimaryItemID = uint32(r.uint16())
+			} else {
+				file.PrimaryItemID = r.uint32()
+			}
+		case "iinf":
+			err = parseIinf(r, getItem)
+		case "iloc":
+			err = parseIloc(r, getItem)
+		case "iref":
+			err = parseIref(r, getItem)
+		case "idat":
+			file.idat = data[box.Start:box.End]
+		}
+		if r.err != nil {
+			err = r.err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse HEIF %s box: %w", box.Type, err)
+		}
+	}
+
+	for _, id := range order {
+		file.Items = append(file.Items, *items[id])
+	}
+
+	return file, nil
+}
+
+func parseIinf(r *heifReader, getItem func(uint32) *HEIFItem) error {
+	version, _ := r.fullBoxHeader()
+	if version == 0 {
+		r.uint16()
+	} else {
+		r.uint32()
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	entries, err := readBoxes(NewBytesSource(r.data), r.pos, r.end)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Type != "infe" {
+			continue
+		}
+
+		er := &heifReader{data: r.data, pos: entry.Start, end: entry.End}
+		infeVersion, _ := er.fullBoxHeader()
+
+		var item *HEIFItem
+		switch {
+		case infeVersion < 2:
+			item = getItem(uint32(er.uint16()))
+			er.uint16() // protection index
+			item.Name = er.string()
+			item.ContentType = er.string()
+		default:
+			if infeVersion == 2 {
+				item = getItem(uint32(er.uint16()))
+			} else {
+				item = getItem(er.uint32())
+			}
+			er.uint16() // protection index
+			item.Type = string(er.take(4))
+			item.Name = er.string()
+			if item.Type == "mime" {
+				item.ContentType = er.string()
+			}
+		}
+
+		if er.err != nil {
+			return er.err
+		}
+	}
+	return nil
+}
+
+func parseIloc(r *heifReader, getItem func(uint32) *HEIFItem) error {
+	version, _ := r.fullBoxHeader()
+	sizes := r.uint16()
+	offsetSize := int(sizes >> 12)
+	lengthSize := int(sizes >> 8 & 0xf)
+	baseOffsetSize := int(sizes >> 4 & 0xf)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xf)
+	}
+
+	var itemCount uint32
+	if version < 2 {
+		itemCount = uint32(r.uint16())
+	} else {
+		itemCount = r.uint32()
+	}
+
+	for i := uint32(0); i < itemCount && r.err == nil; i++ {
+		var item *HEIFItem
+		if version < 2 {
+			item = getItem(uint32(r.uint16()))
+		} else {
+			item = getItem(r.uint32())
+		}
+
+		if version == 1 || version == 2 {
+			item.ConstructionMethod = uint8(r.uint16() & 0xf)
+		}
+		r.uint16() // data reference index
+		baseOffset := r.uintN(baseOffsetSize)
+
+		extentCount := r.uint16()
+		item.Extents = nil
+		for j := 0; j < int(extentCount) && r.err == nil; j++ {
+			r.uintN(indexSize)
+			offset := r.uintN(offsetSize)
+			length := r.uintN(lengthSize)
+			item.Extents = append(item.Extents, HEIFExtent{Offset: baseOffset + offset, Length: length})
+		}
+	}
+	return r.err
+}
+
+func parseIref(r *heifReader, getItem func(uint32) *HEIFItem) error {
+	version, _ := r.fullBoxHeader()
+	if r.err != nil {
+		return r.err
+	}
+
+	refs, err := readBoxes(NewBytesSource(r.data), r.pos, r.end)
+	if err != nil {
+		return err
+	}
+
+	readID := func(rr *heifReader) uint32 {
+		if version == 0 {
+			return uint32(rr.uint16())
+		}
+		return rr.uint32()
+	}
+
+	for _, ref := range refs {
+		rr := &heifReader{data: r.data, pos: ref.Start, end: ref.End}
+		from := getItem(readID(rr))
+		count := rr.uint16()
+		for i := 0; i < int(count) && rr.err == nil; i++ {
+			from.References[ref.Type] = append(from.References[ref.Type], readID(rr))
+		}
+		if rr.err != nil {
+			return rr.err
+		}
+	}
+	return nil
+}
+
+// FindItem returns the first item of the given type. For "mime" items, contentType must also match.
+// Items describing the primary image (via a cdsc reference) are preferred.
+func (f *HEIFFile) FindItem(itemType, contentType string) (HEIFItem, bool) {
+	var found *HEIFItem
+	for i := range f.Items {
+		item := &f.Items[i]
+		if item.Type != itemType || (contentType != "" && item.ContentType != contentType) {
+			continue
+		}
+		for _, target := range item.References["cdsc"] {
+			if target == f.PrimaryItemID {
+				return *item, true
+			}
+		}
+		if found == nil {
+			found = item
+		}
+	}
+
+	if found == nil {
+		return HEIFItem{}, false
+	}
+	return *found, true
+}
+
+// ItemData assembles an item's bytes from its extents. When the item is stored as a single extent in
+// the file itself, the returned offset is its position within src, otherwise it is -1.
+func (f *HEIFFile) ItemData(src *Source, item HEIFItem) ([]byte, int, error) {
+	var source *Source
+	switch item.ConstructionMethod {
+	case ConstructionFileOffset:
+		source = src
+	case ConstructionIdatOffset:
+		source = NewBytesSource(f.idat)
+	default:
+		return nil, -1, fmt.Errorf("unsupported construction method %d for item %d", item.ConstructionMethod, item.ID)
+	}
+
+	if len(item.Extents) == 0 {
+		return nil, -1, fmt.Errorf("item %d has no location", item.ID)
+	}
+
+	size := uint64(source.Size())
+	var out []byte
+	for _, extent := range item.Extents {
+		length := extent.Length
+		if length == 0 {
+			// Zero length means the rest of the source
+			length = size - min(extent.Offset, size)
+		}
+		if extent.Offset > size || length > size-extent.Offset {
+			return nil, -1, fmt.Errorf("item %d extent out of range (offset %d, length %d)", item.ID, extent.Offset, length)
+		}
+		// Repeated extents could otherwise grow the item far beyond the file
+		if length > size-uint64(len(out)) {
+			return nil, -1, fmt.Errorf("item %d is larger than its source", item.ID)
+		}
+		chunk, err := source.Slice(int(extent.Offset), int(length))
+		if err != nil {
+			return nil, -1, fmt.Errorf("cannot read item %d: %w", item.ID, err)
+		}
+		out = append(out, chunk...)
+	}
+
+	if item.ConstructionMethod == ConstructionFileOffset && len(item.Extents) == 1 {
+		return out, int(item.Extents[0].Offset), nil
+	}
+	return out, -1, nil
+}
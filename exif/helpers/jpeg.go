@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// JPEG marker codes (the byte following 0xFF)
+const (
+	MarkerSOF0  byte = 0xc0
+	MarkerSOF15 byte = 0xcf
+	MarkerDHT   byte = 0xc4
+	MarkerJPG   byte = 0xc8
+	MarkerDAC   byte = 0xcc
+	MarkerRST0  byte = 0xd0
+	MarkerRST7  byte = 0xd7
+	MarkerSOI   byte = 0xd8
+	MarkerEOI   byte = 0xd9
+	MarkerSOS   byte = 0xda
+	MarkerDQT   byte = 0xdb
+	MarkerDNL   byte = 0xdc
+	MarkerDRI   byte = 0xdd
+	MarkerAPP0  byte = 0xe0
+	MarkerAPP1  byte = 0xe1
+	MarkerAPP2  byte = 0xe2
+	MarkerAPP15 byte = 0xef
+	MarkerCOM   byte = 0xfe
+)
+
+// APPn payload signatures
+const (
+	ExifSignature   = "Exif\x00\x00"
+	XMPSignature    = "http://ns.adobe.com/xap/1.0/\x00"
+	ExtXMPSignature = "http://ns.adobe.com/xmp/extension/\x00"
+)
+
+// JPEGSegment A single marker segment found while walking a JPEG file
+type JPEGSegment struct {
+	Marker byte   `json:"marker"`
+	Name   string `json:"name"`
+	// Offset of the 0xFF byte that starts the marker
+	Offset int `json:"offset"`
+	// Length as stored in the segment header, including the two length bytes.
+	// Zero for standalone markers such as SOI and EOI.
+	Length int `json:"length"`
+	// DataOffset is where the payload starts, just after the length field
+	DataOffset int `json:"dataOffset"`
+}
+
+// Payload returns the segment's payload bytes, excluding the marker and length field
+func (s JPEGSegment) Payload(src *Source) []byte {
+	if s.Length < 2 {
+		return nil
+	}
+	payload, err := src.Slice(s.DataOffset, s.Length-2)
+	if err != nil {
+		return nil
+	}
+	return payload
+}
+
+// HasSignature reports whether the segment payload starts with the given signature, reading only the signature
+func (s JPEGSegment) HasSignature(src *Source, signature string) bool {
+	if s.Length-2 < len(signature) {
+		return false
+	}
+	prefix, err := src.Slice(s.DataOffset, len(signature))
+	return err == nil && string(prefix) == signature
+}
+
+// ReadJPEGSegments walks the marker chain of a JPEG file starting from SOI, following each segment's
+// length rather than scanning for marker bytes. If the file is truncated, the segments found so far
+// are returned alongside the error.
+//
+// The walk deliberately stops at the first SOS. Every metadata segment comes before the image data,
+// and the markers between the scans of a progressive JPEG (further DHT, SOS and so on) can only be
+// found by reading through the entropy-coded data, which would mean reading the whole file.
+func ReadJPEGSegments(src *Source) ([]JPEGSegment, error) {
+	if soi, err := src.Slice(0, 2); err != nil || soi[0] != 0xFF || soi[1] != MarkerSOI {
+		return nil, errors.New("file is not a JPEG")
+	}
+
+	segments := []JPEGSegment{{Marker: MarkerSOI, Name: MarkerName(MarkerSOI), Offset: 0, DataOffset: 2}}
+	pos := 2
+
+	for pos < src.Size() {
+		if b, err := src.Byte(pos); err != nil || b != 0xFF {
+			return segments, fmt.Errorf("expected marker at offset %d, got %#x", pos, b)
+		}
+
+		// Markers may be preceded by any number of 0xFF fill bytes
+		markerPos := pos
+		marker := byte(0xFF)
+		for marker == 0xFF {
+			pos++
+			b, err := src.Byte(pos)
+			if err != nil {
+				return segments, fmt.Errorf("truncated JPEG marker: %w", err)
+			}
+			marker = b
+		}
+		pos++
+
+		segment := JPEGSegment{
+			Marker:     marker,
+			Name:       MarkerName(marker),
+			Offset:     markerPos,
+			DataOffset: pos,
+		}
+
+		if isStandaloneMarker(marker) {
+			segments = append(segments, segment)
+			if marker == MarkerEOI {
+				return segments, nil
+			}
+			continue
+		}
+
+		header, err := src.Slice(pos, 2)
+		if err != nil {
+			return segments, fmt.Errorf("truncated %s segment length at offset %d", segment.Name, markerPos)
+		}
+		length := int(header[0])<<8 | int(header[1])
+		if length < 2 {
+			return segments, fmt.Errorf("invalid %s segment length %d at offset %d", segment.Name, length, markerPos)
+		}
+		segment.Length = length
+		segment.DataOffset = pos + 2
+
+		if pos+length > src.Size() {
+			return segments, fmt.Errorf("%s segment at offset %d overruns file", segment.Name, markerPos)
+		}
+		segments = append(segments, segment)
+		pos += length
+
+		if marker == MarkerSOS {
+			return segments, nil
+		}
+	}
+
+	return segments, errors.New("JPEG ended without EOI marker")
+}
+
+func isStandaloneMarker(marker byte) bool {
+	return marker == MarkerSOI || marker == MarkerEOI || marker == 0x01 ||
+		(marker >= MarkerRST0 && marker <= MarkerRST7)
+}
+
+// FindJPEGSegment returns the first segment with the given marker whose payload begins with signature
+func FindJPEGSegment(src *Source, segments []JPEGSegment, marker byte, signature string) (JPEGSegment, bool) {
+	for _, segment := range segments {
+		if segment.Marker == marker && segment.HasSignature(src, signature) {
+			return segment, true
+		}
+	}
+	return JPEGSegment{}, false
+}
+
+// MarkerName returns the conventional mnemonic for a JPEG marker
+func MarkerName(marker byte) string {
+	switch {
+	case marker == MarkerDHT:
+		return "DHT"
+	case marker == MarkerJPG:
+		return "JPG"
+	case marker == MarkerDAC:
+		return "DAC"
+	case marker >= MarkerSOF0 && marker <= MarkerSOF15:
+		return fmt.Sprintf("SOF%d", marker-MarkerSOF0)
+	case marker >= MarkerRST0 && marker <= MarkerRST7:
+		return fmt.Sprintf("RST%d", marker-MarkerRST0)
+	case marker == MarkerSOI:
+		return "SOI"
+	case marker == MarkerEOI:
+		return "EOI"
+	case marker == MarkerSOS:
+		return "SOS"
+	case marker == MarkerDQT:
+		return "DQT"
+	case marker == MarkerDNL:
+		return "DNL"
+	case marker == MarkerDRI:
+		return "DRI"
+	case marker >= MarkerAPP0 && marker <= MarkerAPP15:
+		return fmt.Sprintf("APP%d", marker-MarkerAPP0)
+	case marker == MarkerCOM:
+		return "COM"
+	default:
+		return fmt.Sprintf("0x%02X", marker)
+	}
+}
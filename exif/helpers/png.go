@@ -0,0 +1,211 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// maxInflatedText caps decompressed zTXt/iTXt chunks so a small file cannot expand without bound
+const maxInflatedText = MaxBlobSize
+
+// pngMetadataChunks are the chunks ReadPNGMetadata reads and decodes
+var pngMetadataChunks = map[string]bool{"IHDR": true, "eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true}
+
+// PNGMetadata Metadata chunks collected from a PNG file
+type PNGMetadata struct {
+	Width  int
+	Height int
+	// Exif holds the TIFF structure from eXIf or a legacy "Raw profile type exif" text chunk
+	Exif []byte
+	// ExifOffset is the file offset of Exif when it was stored uncompressed in eXIf, otherwise -1
+	ExifOffset int
+	XMP        string
+	Text       map[string]string
+}
+
+// IsPNG checks for the 8 byte PNG signature
+func IsPNG(data []byte) bool {
+	return len(data) >= len(pngSignature) && string(data[:len(pngSignature)]) == pngSignature
+}
+
+// ReadPNGMetadata walks the PNG chunk list, collecting EXIF, XMP and textual key/value chunks. Only
+// the chunks it decodes are read and CRC checked, image data is skipped over.
+func ReadPNGMetadata(src *Source, ctx *ParseContext) (*PNGMetadata, error) {
+	if head, err := src.Slice(0, min(src.Size(), len(pngSignature))); err != nil || !IsPNG(head) {
+		return nil, errors.New("file is not a PNG")
+	}
+
+	meta := &PNGMetadata{ExifOffset: -1, Text: map[string]string{}}
+	var rawExif, rawXMP string
+
+	pos := len(pngSignature)
+	for pos+8 <= src.Size() {
+		header, err := src.Slice(pos, 8)
+		if err != nil {
+			return meta, err
+		}
+		length := int(binary.BigEndian.Uint32(header[0:4]))
+		chunkType := string(header[4:8])
+		start := pos + 8
+		if length < 0 || length > src.Size()-start-4 {
+			return meta, fmt.Errorf("%s chunk at offset %d overruns file", chunkType, pos)
+		}
+
+		if chunkType == "IEND" {
+			break
+		}
+		// Image data and chunks we do not decode are skipped without being read
+		if !pngMetadataChunks[chunkType] {
+			pos = start + length + 4
+			continue
+		}
+
+		raw, err := src.Slice(pos+4, length+8)
+		if err != nil {
+			return meta, err
+		}
+		chunk := raw[4 : 4+length]
+		if crc := binary.BigEndian.Uint32(raw[4+length:]); crc != crc32.ChecksumIEEE(raw[:4+length]) {
+			ctx.Warn(DiagChecksumMismatch, "PNG", pos, "PNG chunk CRC mismatch", "chunk", chunkType)
+		}
+
+		switch chunkType {
+		case "IHDR":
+			if length >= 8 {
+				meta.Width = int(binary.BigEndian.Uint32(chunk[0:4]))
+				meta.Height = int(binary.BigEndian.Uint32(chunk[4:8]))
+			}
+		case "eXIf":
+			meta.Exif = chunk
+			meta.ExifOffset = start
+		case "tEXt", "zTXt", "iTXt":
+			keyword, text, err := decodePNGText(chunkType, chunk)
+			if err != nil {
+				ctx.Warn(DiagDecodeFailed, "PNG", pos, "cannot decode PNG text chunk: "+err.Error(), "chunk", chunkType)
+				break
+			}
+
+			switch {
+			case keyword == "XML:com.adobe.xmp":
+				meta.XMP = text
+			case strings.EqualFold(keyword, "Raw profile type exif"), strings.EqualFold(keyword, "Raw profile type APP1"):
+				rawExif = text
+			case strings.EqualFold(keyword, "Raw profile type xmp"):
+				rawXMP = text
+			case strings.HasPrefix(keyword, "Raw profile type "):
+				// Other embedded profiles (ICC, IPTC) are binary blobs rather than text
+			default:
+				meta.Text[keyword] = text
+			}
+		}
+
+		pos = start + length + 4
+	}
+
+	if meta.Exif == nil && rawExif != "" {
+		profile, err := decodeRawProfile(rawExif, ctx)
+		if err != nil {
+			ctx.Warn(DiagDecodeFailed, "PNG", -1, "cannot decode legacy EXIF raw profile: "+err.Error())
+		} else {
+			meta.Exif = profile
+		}
+	}
+	if meta.XMP == "" && rawXMP != "" {
+		profile, err := decodeRawProfile(rawXMP, ctx)
+		if err != nil {
+			ctx.Warn(DiagDecodeFailed, "PNG", -1, "cannot decode legacy XMP raw profile: "+err.Error())
+		} else {
+			meta.XMP = string(profile)
+		}
+	}
+
+	return meta, nil
+}
+
+// decodePNGText splits a tEXt, zTXt or iTXt chunk into its keyword and (decompressed) text
+func decodePNGText(chunkType string, chunk []byte) (string, string, error) {
+	sep := bytes.IndexByte(chunk, 0)
+	if sep == -1 {
+		return "", "", errors.New("missing keyword terminator")
+	}
+	keyword := string(chunk[:sep])
+	rest := chunk[sep+1:]
+
+	switch chunkType {
+	case "tEXt":
+		return keyword, string(rest), nil
+	case "zTXt":
+		if len(rest) < 1 {
+			return "", "", errors.New("missing compression method")
+		}
+		text, err := inflatePNGText(rest[1:])
+		return keyword, text, err
+	default:
+		// iTXt: compression flag, compression method, language tag\0, translated keyword\0, text
+		if len(rest) < 2 {
+			return "", "", errors.New("truncated iTXt header")
+		}
+		compressed := rest[0] == 1
+		rest = rest[2:]
+		for i := 0; i < 2; i++ {
+			sep = bytes.IndexByte(rest, 0)
+			if sep == -1 {
+				return "", "", errors.New("truncated iTXt header")
+			}
+			rest = rest[sep+1:]
+		}
+		if compressed {
+			text, err := inflatePNGText(rest)
+			return keyword, text, err
+		}
+		return keyword, string(rest), nil
+	}
+}
+
+func inflatePNGText(compressed []byte) (string, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	text, err := io.ReadAll(io.LimitReader(reader, maxInflatedText))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// decodeRawProfile decodes ImageMagick's "Raw profile type" text: a newline, the profile name,
+// the byte length, then the profile as hex split over several lines
+func decodeRawProfile(text string, ctx *ParseContext) ([]byte, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 {
+		return nil, errors.New("raw profile is missing its header")
+	}
+
+	length, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw profile length: %w", err)
+	}
+
+	profile, err := hex.DecodeString(strings.Join(fields[2:], ""))
+	if err != nil {
+		return nil, err
+	}
+	if len(profile) != length {
+		ctx.Warn(DiagLengthMismatch, "PNG", -1, "raw profile length mismatch", "declared", length, "decoded", len(profile))
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// sourceReadAhead is how much a small read pulls in, so the entries of an IFD and the values next
+// to it are served by one read of the underlying file
+const sourceReadAhead = 8 << 10
+
+// MaxBlobSize caps any single blob copied or inflated out of a file, such as a thumbnail, a
+// compressed text chunk or an HDR+ MakerNote, so a small file cannot claim memory without bound
+const MaxBlobSize = 16 << 20
+
+// Source Random access to the file being parsed. Bytes are fetched from the underlying reader on
+// demand, so only the headers, segments and IFDs that hold metadata are ever loaded.
+type Source struct {
+	r io.ReaderAt
+	// start and size bound the readable range, which is the whole file unless this is a Section
+	start int
+	size  int
+	// data is the whole file when parsing from memory, which is sliced rather than copied
+	data []byte
+
+	window       []byte
+	windowOffset int
+}
+
+// NewSource reads a file of the given size through r
+func NewSource(r io.ReaderAt, size int64) *Source {
+	return &Source{r: r, size: int(size)}
+}
+
+// NewBytesSource wraps a file already held in memory
+func NewBytesSource(data []byte) *Source {
+	return &Source{data: data, size: len(data)}
+}
+
+// NewReadSeekerSource reads a file through rs, using its ReadAt method when it has one and
+// seeking before every read otherwise. The size is found by seeking to the end.
+func NewReadSeekerSource(rs io.ReadSeeker) (*Source, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine file size: %w", err)
+	}
+
+	if r, ok := rs.(io.ReaderAt); ok {
+		return NewSource(r, size), nil
+	}
+	return NewSource(readSeekerAt{rs}, size), nil
+}
+
+// Size returns the length of the file, or for a Section the offset of its end
+func (s *Source) Size() int {
+	return s.size
+}
+
+// Section returns a view of the length bytes at offset, such as the segment or chunk holding a TIFF
+// structure. Offsets are unchanged, so positions reported while parsing it are still file offsets,
+// but IFD and value pointers cannot reach the rest of the file.
+func (s *Source) Section(offset, length int) (*Source, error) {
+	if offset < s.start || length < 0 || offset > s.size || length > s.size-offset {
+		return nil, fmt.Errorf("section of %d bytes at offset %d: %w", length, offset, ErrTruncated)
+	}
+	return &Source{r: s.r, data: s.data, start: offset, size: offset + length}, nil
+}
+
+// Slice returns length bytes starting at offset. A range outside the file, or the section, returns
+// an error wrapping ErrTruncated. The returned bytes may be shared with the source and must not be
+// modified.
+func (s *Source) Slice(offset, length int) ([]byte, error) {
+	if offset < s.start || length < 0 || offset > s.size || length > s.size-offset {
+		return nil, fmt.Errorf("%d bytes at offset %d: %w", length, offset, ErrTruncated)
+	}
+
+	if s.data != nil {
+		return s.data[offset : offset+length : offset+length], nil
+	}
+
+	if offset >= s.windowOffset && offset+length <= s.windowOffset+len(s.window) {
+		start := offset - s.windowOffset
+		return s.window[start : start+length : start+length], nil
+	}
+
+	// Large reads such as thumbnails and MakerNotes go straight to the reader
+	if length > sourceReadAhead {
+		buf := make([]byte, length)
+		if err := s.readAt(buf, offset); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+
+	buf := make([]byte, min(sourceReadAhead, s.size-offset))
+	if err := s.readAt(buf, offset); err != nil {
+		return nil, err
+	}
+	s.window, s.windowOffset = buf, offset
+	return buf[:length:length], nil
+}
+
+// Byte returns the byte at offset
+func (s *Source) Byte(offset int) (byte, error) {
+	b, err := s.Slice(offset, 1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (s *Source) readAt(buf []byte, offset int) error {
+	n, err := s.r.ReadAt(buf, int64(offset))
+	if n == len(buf) {
+		return nil
+	}
+	// The file shrank since its size was taken
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%d bytes at offset %d, read %d: %w", len(buf), offset, n, ErrTruncated)
+	}
+	return fmt.Errorf("cannot read %d bytes at offset %d: %w", len(buf), offset, err)
+}
+
+// readSeekerAt reads at an offset by seeking first, which is safe as Source reads one at a time
+type readSeekerAt struct {
+	rs io.ReadSeeker
+}
+
+func (r readSeekerAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := r.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(r.rs, p)
+}
@@ -0,0 +1,359 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TIFF field data types
+const (
+	TypeByte      uint16 = 1
+	TypeASCII     uint16 = 2
+	TypeShort     uint16 = 3
+	TypeLong      uint16 = 4
+	TypeRational  uint16 = 5
+	TypeSByte     uint16 = 6
+	TypeUndefined uint16 = 7
+	TypeSShort    uint16 = 8
+	TypeSLong     uint16 = 9
+	TypeSRational uint16 = 10
+	TypeFloat     uint16 = 11
+	TypeDouble    uint16 = 12
+	TypeIFD       uint16 = 13
+	TypeLong8     uint16 = 16
+	TypeSLong8    uint16 = 17
+	TypeIFD8      uint16 = 18
+)
+
+// TypeSize returns the size in bytes of a single value of the given type, or 0 for unknown types
+func TypeSize(dataType uint16) int {
+	switch dataType {
+	case TypeByte, TypeASCII, TypeSByte, TypeUndefined:
+		return 1
+	case TypeShort, TypeSShort:
+		return 2
+	case TypeLong, TypeSLong, TypeFloat, TypeIFD:
+		return 4
+	case TypeRational, TypeSRational, TypeDouble, TypeLong8, TypeSLong8, TypeIFD8:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// TypeName returns the TIFF specification name of a data type
+func TypeName(dataType uint16) string {
+	switch dataType {
+	case TypeByte:
+		return "BYTE"
+	case TypeASCII:
+		return "ASCII"
+	case TypeShort:
+		return "SHORT"
+	case TypeLong:
+		return "LONG"
+	case TypeRational:
+		return "RATIONAL"
+	case TypeSByte:
+		return "SBYTE"
+	case TypeUndefined:
+		return "UNDEFINED"
+	case TypeSShort:
+		return "SSHORT"
+	case TypeSLong:
+		return "SLONG"
+	case TypeSRational:
+		return "SRATIONAL"
+	case TypeFloat:
+		return "FLOAT"
+	case TypeDouble:
+		return "DOUBLE"
+	case TypeIFD:
+		return "IFD"
+	case TypeLong8:
+		return "LONG8"
+	case TypeSLong8:
+		return "SLONG8"
+	case TypeIFD8:
+		return "IFD8"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", dataType)
+	}
+}
+
+// Rational An unsigned RATIONAL value
+type Rational struct {
+	Numerator   uint32 `json:"numerator"`
+	Denominator uint32 `json:"denominator"`
+}
+
+func (r Rational) Float() float64 {
+	if r.Denominator == 0 {
+		return 0
+	}
+	return float64(r.Numerator) / float64(r.Denominator)
+}
+
+// SRational A signed SRATIONAL value
+type SRational struct {
+	Numerator   int32 `json:"numerator"`
+	Denominator int32 `json:"denominator"`
+}
+
+func (r SRational) Float() float64 {
+	if r.Denominator == 0 {
+		return 0
+	}
+	return float64(r.Numerator) / float64(r.Denominator)
+}
+
+// TagValue A decoded IFD entry value. Value holds a typed slice matching Type:
+// []uint8 (BYTE), string (ASCII), []uint16, []uint32 (LONG, IFD), []Rational, []int8, []byte (UNDEFINED),
+// []int16, []int32, []SRational, []float32, []float64, []uint64 (LONG8, IFD8) or []int64.
+type TagValue struct {
+	Type  uint16 `json:"type"`
+	Count uint32 `json:"count"`
+	// Offset is the absolute position of the value bytes in the file, or in the reassembled EXIF
+	// block when the container stored it compressed or in pieces
+	Offset int         `json:"offset"`
+	Raw    []byte      `json:"raw"`
+	Value  interface{} `json:"value"`
+}
+
+// DecodeValue converts raw bytes to a TagValue of count values of dataType
+func DecodeValue(raw []byte, dataType uint16, count uint32, endian binary.ByteOrder) TagValue {
+	value := TagValue{Type: dataType, Count: count, Raw: raw}
+	n := int(count)
+	if size := TypeSize(dataType); size == 0 || len(raw) < n*size {
+		value.Value = raw
+		return value
+	}
+
+	switch dataType {
+	case TypeByte:
+		value.Value = raw[:n]
+	case TypeASCII:
+		value.Value = strings.TrimRight(string(raw[:n]), "\x00")
+	case TypeUndefined:
+		value.Value = raw[:n]
+	case TypeSByte:
+		values := make([]int8, n)
+		for i := range values {
+			values[i] = int8(raw[i])
+		}
+		value.Value = values
+	case TypeShort:
+		values := make([]uint16, n)
+		for i := range values {
+			values[i] = endian.Uint16(raw[i*2:])
+		}
+		value.Value = values
+	case TypeSShort:
+		values := make([]int16, n)
+		for i := range values {
+			values[i] = int16(endian.Uint16(raw[i*2:]))
+		}
+		value.Value = values
+	case TypeLong, TypeIFD:
+		values := make([]uint32, n)
+		for i := range values {
+			values[i] = endian.Uint32(raw[i*4:])
+		}
+		value.Value = values
+	case TypeSLong:
+		values := make([]int32, n)
+		for i := range values {
+			values[i] = int32(endian.Uint32(raw[i*4:]))
+		}
+		value.Value = values
+	case TypeRational:
+		values := make([]Rational, n)
+		for i := range values {
+			values[i] = Rational{endian.Uint32(raw[i*8:]), endian.Uint32(raw[i*8+4:])}
+		}
+		value.Value = values
+	case TypeSRational:
+		values := make([]SRational, n)
+		for i := range values {
+			values[i] = SRational{int32(endian.Uint32(raw[i*8:])), int32(endian.Uint32(raw[i*8+4:]))}
+		}
+		value.Value = values
+	case TypeFloat:
+		values := make([]float32, n)
+		for i := range values {
+			values[i] = math.Float32frombits(endian.Uint32(raw[i*4:]))
+		}
+		value.Value = values
+	case TypeDouble:
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = math.Float64frombits(endian.Uint64(raw[i*8:]))
+		}
+		value.Value = values
+	case TypeLong8, TypeIFD8:
+		values := make([]uint64, n)
+		for i := range values {
+			values[i] = endian.Uint64(raw[i*8:])
+		}
+		value.Value = values
+	case TypeSLong8:
+		values := make([]int64, n)
+		for i := range values {
+			values[i] = int64(endian.Uint64(raw[i*8:]))
+		}
+		value.Value = values
+	}
+
+	return value
+}
+
+// Len returns the number of values decoded
+func (v TagValue) Len() int {
+	switch values := v.Value.(type) {
+	case string:
+		return len(values)
+	case []uint8:
+		return len(values)
+	case []int8:
+		return len(values)
+	case []uint16:
+		return len(values)
+	case []int16:
+		return len(values)
+	case []uint32:
+		return len(values)
+	case []int32:
+		return len(values)
+	case []uint64:
+		return len(values)
+	case []int64:
+		return len(values)
+	case []Rational:
+		return len(values)
+	case []SRational:
+		return len(values)
+	case []float32:
+		return len(values)
+	case []float64:
+		return len(values)
+	default:
+		return 0
+	}
+}
+
+// Int returns the i-th value as an integer, truncating rationals and floats. Out of range indexes return 0.
+func (v TagValue) Int(i int) int64 {
+	if i < 0 || i >= v.Len() {
+		return 0
+	}
+
+	switch values := v.Value.(type) {
+	case string:
+		return int64(values[i])
+	case []uint8:
+		return int64(values[i])
+	case []int8:
+		return int64(values[i])
+	case []uint16:
+		return int64(values[i])
+	case []int16:
+		return int64(values[i])
+	case []uint32:
+		return int64(values[i])
+	case []int32:
+		return int64(values[i])
+	case []uint64:
+		return int64(values[i])
+	case []int64:
+		return values[i]
+	default:
+		return int64(v.Float(i))
+	}
+}
+
+// Float returns the i-th value as a float64, dividing rationals. Out of range indexes return 0.
+func (v TagValue) Float(i int) float64 {
+	if i < 0 || i >= v.Len() {
+		return 0
+	}
+
+	switch values := v.Value.(type) {
+	case []Rational:
+		return values[i].Float()
+	case []SRational:
+		return values[i].Float()
+	case []float32:
+		return float64(values[i])
+	case []float64:
+		return values[i]
+	default:
+		return float64(v.Int(i))
+	}
+}
+
+// Rational returns the numerator and denominator of the i-th RATIONAL or SRATIONAL value
+func (v TagValue) Rational(i int) (int64, int64) {
+	if i < 0 || i >= v.Len() {
+		return 0, 0
+	}
+
+	switch values := v.Value.(type) {
+	case []Rational:
+		return int64(values[i].Numerator), int64(values[i].Denominator)
+	case []SRational:
+		return int64(values[i].Numerator), int64(values[i].Denominator)
+	default:
+		return v.Int(i), 1
+	}
+}
+
+// Floats returns every value as a float64
+func (v TagValue) Floats() []float64 {
+	result := make([]float64, v.Len())
+	for i := range result {
+		result[i] = v.Float(i)
+	}
+	return result
+}
+
+// Uint32s returns every value as a uint32, as used by offset and dimension arrays
+func (v TagValue) Uint32s() []uint32 {
+	result := make([]uint32, v.Len())
+	for i := range result {
+		result[i] = uint32(v.Int(i))
+	}
+	return result
+}
+
+// String returns ASCII values with trailing NULs removed, and other types formatted space-separated
+func (v TagValue) String() string {
+	switch values := v.Value.(type) {
+	case string:
+		return values
+	case []uint8:
+		if v.Type == TypeUndefined {
+			return strings.TrimRight(string(values), "\x00")
+		}
+	}
+
+	parts := make([]string, v.Len())
+	for i := range parts {
+		switch v.Value.(type) {
+		case []Rational, []SRational:
+			num, den := v.Rational(i)
+			parts[i] = fmt.Sprintf("%d/%d", num, den)
+		case []float32, []float64:
+			parts[i] = fmt.Sprintf("%g", v.Float(i))
+		default:
+			parts[i] = fmt.Sprintf("%d", v.Int(i))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Bytes returns the raw value bytes as stored in the file
+func (v TagValue) Bytes() []byte {
+	return v.Raw
+}
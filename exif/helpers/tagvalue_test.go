@@ -0,0 +1,206 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	// Every value is encoded in the byte order under test, then read back through an IFD entry. Types
+	// of 8 bytes never fit inline.
+	tests := []struct {
+		dataType uint16
+		inline   interface{}
+		offset   interface{}
+	}{
+		{TypeByte, []uint8{1, 2, 3}, []uint8{1, 2, 3, 4, 5, 6}},
+		{TypeASCII, "abc", "abcdefg"},
+		{TypeShort, []uint16{0x0102, 0x0304}, []uint16{0x0102, 0x0304, 0x0506}},
+		{TypeLong, []uint32{0x01020304}, []uint32{0x01020304, 0x05060708}},
+		{TypeRational, nil, []Rational{{1, 3}, {72, 1}}},
+		{TypeSByte, []int8{-1, 2, -3, 4}, []int8{-1, 2, -3, 4, -5}},
+		{TypeUndefined, []uint8{'0', '2', '3', '2'}, []uint8{'A', 'S', 'C', 'I', 'I', 0, 0, 0}},
+		{TypeSShort, []int16{-2, 300}, []int16{-2, 300, -400}},
+		{TypeSLong, []int32{-70000}, []int32{-70000, 70000}},
+		{TypeSRational, nil, []SRational{{-1, 3}, {5, -7}}},
+		{TypeFloat, []float32{1.5}, []float32{1.5, -0.25}},
+		{TypeDouble, nil, []float64{3.141592653589793}},
+		{TypeIFD, []uint32{0x1a}, []uint32{0x1a, 0x2b}},
+		{TypeLong8, nil, []uint64{1 << 40}},
+		{TypeSLong8, nil, []int64{-1 << 40}},
+		{TypeIFD8, nil, []uint64{0x100000000}},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		for _, tt := range tests {
+			for _, storage := range []string{"inline", "offset"} {
+				want := tt.inline
+				if storage == "offset" {
+					want = tt.offset
+				}
+				if want == nil {
+					continue
+				}
+
+				t.Run(fmt.Sprintf("%v/%s/%s", order, TypeName(tt.dataType), storage), func(t *testing.T) {
+					var raw []byte
+					if s, ok := want.(string); ok {
+						raw = []byte(s + "\x00")
+					} else {
+						var buf bytes.Buffer
+						if err := binary.Write(&buf, order, want); err != nil {
+							t.Fatal(err)
+						}
+						raw = buf.Bytes()
+					}
+					count := uint32(len(raw) / TypeSize(tt.dataType))
+
+					// Four bytes of another segment come before the TIFF header, then the header and
+					// a single entry at 12, so values stored elsewhere follow at TIFF offset 20
+					const tiffStart, entryOffset = 4, 12
+					data := make([]byte, tiffStart+20)
+					order.PutUint16(data[entryOffset:], 0x1234)
+					order.PutUint16(data[entryOffset+2:], tt.dataType)
+					order.PutUint32(data[entryOffset+4:], count)
+					wantOffset := entryOffset + 8
+					if len(raw) <= 4 {
+						copy(data[wantOffset:], raw)
+					} else {
+						order.PutUint32(data[entryOffset+8:], 20)
+						wantOffset = tiffStart + 20
+						data = append(data, raw...)
+					}
+					if (len(raw) <= 4) != (storage == "inline") {
+						t.Fatalf("%d bytes are not stored %s", len(raw), storage)
+					}
+
+					e := &ValueExtractor{Source: NewBytesSource(data), TiffStart: tiffStart, Endian: order}
+					entry := IFDEntry{Tag: 0x1234, DataType: tt.dataType, Count: count, ValueOffset: order.Uint32(data[entryOffset+8:]), Offset: entryOffset}
+					value, err := e.Decode(entry)
+					if err != nil {
+						t.Fatalf("Decode() error = %v", err)
+					}
+					if !reflect.DeepEqual(value.Value, want) {
+						t.Errorf("Value = %#v, want %#v", value.Value, want)
+					}
+					if value.Offset != wantOffset || !bytes.Equal(value.Raw, raw) {
+						t.Errorf("Offset = %d, Raw = %x, want %d, %x", value.Offset, value.Raw, wantOffset, raw)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeOutOfRange(t *testing.T) {
+	data := make([]byte, 24)
+	e := &ValueExtractor{Source: NewBytesSource(data), Endian: binary.BigEndian}
+
+	tests := []struct {
+		name  string
+		entry IFDEntry
+	}{
+		{"offset past end", IFDEntry{DataType: TypeLong, Count: 2, ValueOffset: 20}},
+		{"count overflows", IFDEntry{DataType: TypeDouble, Count: 0xffffffff, ValueOffset: 8}},
+		{"unknown type", IFDEntry{DataType: 99, Count: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value, err := e.Decode(tt.entry); err == nil {
+				t.Errorf("Decode() = %+v, want an error", value)
+			}
+		})
+	}
+}
+
+func TestDecodeValueShortRaw(t *testing.T) {
+	// Fewer bytes than the count needs are returned raw rather than read past the end
+	value := DecodeValue([]byte{1, 2, 3}, TypeShort, 2, binary.LittleEndian)
+	if raw, ok := value.Value.([]byte); !ok || len(raw) != 3 {
+		t.Errorf("DecodeValue() = %#v", value)
+	}
+}
+
+func TestDeprecatedGetters(t *testing.T) {
+	// An extractor that only sets Data, as built before Source existed. Entries are at 0, 12 and 24
+	// and values stored elsewhere from 36.
+	order := binary.BigEndian
+	data := make([]byte, 36)
+	put := func(offset int, tag Tag, dataType uint16, count, value uint32) IFDEntry {
+		order.PutUint16(data[offset:], uint16(tag))
+		order.PutUint16(data[offset+2:], dataType)
+		order.PutUint32(data[offset+4:], count)
+		order.PutUint32(data[offset+8:], value)
+		return IFDEntry{Tag: tag, DataType: dataType, Count: count, ValueOffset: value, Offset: offset}
+	}
+	model := put(0, 0x0110, TypeASCII, 8, 36)
+	data = append(data, "EOS R5\x00\x00"...)
+	width := put(12, 0x0100, TypeLong, 1, 6000)
+	latitude := put(24, 0x0002, TypeRational, 3, 44)
+	for _, part := range []uint32{51, 1, 30, 1, 36, 1} {
+		data = order.AppendUint32(data, part)
+	}
+
+	e := &ValueExtractor{Data: data, Endian: order}
+	if got := e.GetString(model, model.Offset); got != "EOS R5" {
+		t.Errorf("GetString() = %q", got)
+	}
+	if got := e.GetUint32(width.Offset); got != 6000 {
+		t.Errorf("GetUint32() = %d", got)
+	}
+	if got := e.GetGPSCoord(latitude); got != 51.51 {
+		t.Errorf("GetGPSCoord() = %v", got)
+	}
+	if value, err := e.Decode(width); err != nil || value.Int(0) != 6000 {
+		t.Errorf("Decode() = %+v, %v", value, err)
+	}
+
+	// Values past the end read as zero rather than panicking
+	model.ValueOffset = 1000
+	if got := e.GetString(model, model.Offset); got != "" {
+		t.Errorf("GetString() past the end = %q", got)
+	}
+	if got := e.GetUint8Array(1000, 2); !bytes.Equal(got, []uint8{0, 0}) {
+		t.Errorf("GetUint8Array() past the end = %v", got)
+	}
+}
+
+func TestWalkIFD(t *testing.T) {
+	// Three entries share the 8 bytes after the IFD or point past the end. Only the nested tag may
+	// share its bytes without an overlap being reported.
+	order := binary.BigEndian
+	data := order.AppendUint16(nil, 3)
+	for _, entry := range [][3]uint32{{0x0001, 2, 42}, {0x0002, 7, 42}, {0x0003, 7, 1000}} {
+		data = order.AppendUint16(data, uint16(entry[0]))
+		data = order.AppendUint16(data, uint16(entry[1]))
+		data = order.AppendUint32(data, 8)
+		data = order.AppendUint32(data, entry[2])
+	}
+	data = append(order.AppendUint32(data, 0), "NESTED\x00\x00"...)
+
+	ctx := &ParseContext{RecordTags: true}
+	e := &ValueExtractor{Source: NewBytesSource(data), Endian: order, Context: ctx}
+	var visited []Tag
+	if _, err := e.WalkIFD("Test", 0, func(entry IFDEntry, value TagValue) {
+		visited = append(visited, entry.Tag)
+	}, 0x0002); err != nil {
+		t.Fatalf("WalkIFD() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(visited, []Tag{0x0001, 0x0002}) {
+		t.Errorf("visited %v, want the two entries in range", visited)
+	}
+	if len(ctx.Tags) != 3 {
+		t.Errorf("recorded %d tags, want 3", len(ctx.Tags))
+	}
+	if len(ctx.Diagnostics) != 1 || ctx.Diagnostics[0].Code != DiagOutOfRange {
+		t.Errorf("want one out_of_range diagnostic, got %v", ctx.Diagnostics)
+	}
+
+	if _, err := e.WalkIFD("Missing", 1000, func(IFDEntry, TagValue) {}); err == nil {
+		t.Error("WalkIFD() past the end: want an error")
+	}
+}
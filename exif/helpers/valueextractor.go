@@ -2,255 +2,357 @@ package helpers
 
 import (
 	"encoding/binary"
-	"encoding/xml"
 	"fmt"
-	"log/slog"
 	"regexp"
+	"slices"
 	"strings"
 	"unicode/utf16"
 )
 
+// ValueExtractor Reads IFDs and tag values from a TIFF structure within Source, fetching only the
+// bytes each one needs
 type ValueExtractor struct {
+	Source *Source
+	// Data is read when Source is nil, for extractors built before Source existed.
+	//
+	// Deprecated: set Source, for example with NewBytesSource.
 	Data      []byte
 	TiffStart int
 	Endian    binary.ByteOrder
+	Context   *ParseContext
 }
 
-// getString extracts a string value from EXIF data
-func (e *ValueExtractor) getString(entryOffset, offset, count int) string {
-	if count <= 4 {
-		return strings.TrimRight(string(e.Data[entryOffset+8:entryOffset+8+count]), "\x00")
+// source returns Source, or a Source over Data for extractors that only set Data
+func (e *ValueExtractor) source() *Source {
+	if e.Source == nil {
+		return NewBytesSource(e.Data)
 	}
-	return strings.TrimRight(string(e.Data[offset:offset+count]), "\x00")
+	return e.Source
 }
 
-// getRational extracts a rational value (numerator/denominator) from EXIF data
-func (e *ValueExtractor) getRational(offset int, signed bool) float64 {
-	if offset < 0 || offset+8 > len(e.Data) {
-		return 0
+// Decode reads the value of an IFD entry according to its data type and count. Values that fit in
+// 4 bytes are stored inline in the entry, larger ones at ValueOffset relative to the TIFF header.
+func (e *ValueExtractor) Decode(entry IFDEntry) (TagValue, error) {
+	size := TypeSize(entry.DataType)
+	if size == 0 {
+		return TagValue{}, fmt.Errorf("tag %#04x has unknown data type %d", entry.Tag, entry.DataType)
 	}
 
-	var numerator float64
-	var denominator float64
+	total := uint64(size) * uint64(entry.Count)
+	offset := uint64(e.TiffStart) + uint64(entry.ValueOffset)
+	if total <= 4 {
+		offset = uint64(entry.Offset) + 8
+	}
 
-	if signed {
-		numerator = float64(int32(e.Endian.Uint32(e.Data[offset : offset+4])))
-		denominator = float64(int32(e.Endian.Uint32(e.Data[offset+4 : offset+8])))
+	src := e.source()
+	if entry.Offset < 0 || offset+total > uint64(src.Size()) {
+		return TagValue{}, fmt.Errorf("tag %#04x value (offset %d, %d bytes) out of range", entry.Tag, offset, total)
+	}
 
-	} else {
-		numerator = float64(e.Endian.Uint32(e.Data[offset : offset+4]))
-		denominator = float64(e.Endian.Uint32(e.Data[offset+4 : offset+8]))
+	raw, err := src.Slice(int(offset), int(total))
+	if err != nil {
+		return TagValue{}, fmt.Errorf("tag %#04x value: %w", entry.Tag, err)
 	}
 
-	if denominator == 0 {
-		return 0
+	value := DecodeValue(raw, entry.DataType, entry.Count, e.Endian)
+	value.Offset = int(offset)
+	return value, nil
+}
+
+// ReadIFD reads the IFD at offset through the parse context, which stops loops and flags overlaps.
+// Errors are as for the package level ReadIFD, plus ErrIFDLoop.
+func (e *ValueExtractor) ReadIFD(name string, offset int) (IFD, error) {
+	ifd, err := ReadIFD(e.source(), offset, e.Endian)
+	if err != nil && len(ifd.Entries) == 0 {
+		return ifd, err
 	}
 
-	return numerator / denominator
+	if loopErr := e.Context.EnterIFD(name, offset, 2+len(ifd.Entries)*12+4); loopErr != nil {
+		return IFD{Offset: offset}, loopErr
+	}
+	return ifd, err
 }
 
-// getRationalParts extracts the raw numerator and denominator from EXIF data
-func (e *ValueExtractor) getRationalParts(offset int) (uint32, uint32) {
-	if offset < 0 || offset+8 > len(e.Data) {
-		return 0, 0
+// WalkIFD reads the IFD at offset and walks its entries with WalkEntries. A partly read IFD is reported
+// to the context and walked; the error, as for ReadIFD, is returned only when no entry could be read.
+func (e *ValueExtractor) WalkIFD(name string, offset int, visit func(IFDEntry, TagValue), nested ...Tag) (IFD, error) {
+	ifd, err := e.ReadIFD(name, offset)
+	if err != nil && len(ifd.Entries) == 0 {
+		return ifd, err
+	}
+	if err != nil {
+		e.Context.IFDError(name, offset, err)
 	}
 
-	numerator := e.Endian.Uint32(e.Data[offset : offset+4])
-	denominator := e.Endian.Uint32(e.Data[offset+4 : offset+8])
+	e.WalkEntries(name, ifd.Entries, visit, nested...)
+	return ifd, nil
+}
 
-	return numerator, denominator
+// WalkEntries decodes each entry and records it in the context. Values out of range are reported and
+// skipped, the rest are claimed and passed to visit. Values of the nested tags hold an IFD of their
+// own, whose entries claim their parts as it is read, so they are not claimed as flat values.
+func (e *ValueExtractor) WalkEntries(name string, entries []IFDEntry, visit func(IFDEntry, TagValue), nested ...Tag) {
+	for _, entry := range entries {
+		e.Context.Log().Debug("IFD entry",
+			"ifd", name,
+			"tag", fmt.Sprintf("%#04x", entry.Tag),
+			"type", entry.DataType,
+			"count", entry.Count,
+			"valueOffset", entry.ValueOffset)
+
+		value, err := e.Decode(entry)
+		e.Context.RecordTag(name, entry, value, err)
+		if err != nil {
+			e.Context.WarnTag(DiagOutOfRange, name, entry, err.Error())
+			continue
+		}
+		if !slices.Contains(nested, entry.Tag) {
+			e.Context.ClaimValue(name, entry, value)
+		}
+
+		visit(entry, value)
+	}
 }
 
-// getGPSCoordinate calculates GPS coordinates from degrees, minutes, seconds
-func (e *ValueExtractor) getGPSCoordinate(offset int) float64 {
-	degrees := e.getRational(offset, false)
-	minutes := e.getRational(offset+8, false)
-	seconds := e.getRational(offset+16, false)
+// DecodeUTF16LE decodes the UTF-16LE strings used by the Windows XP* tags
+func DecodeUTF16LE(raw []byte) string {
+	charCount := len(raw) / 2
+	if charCount == 0 {
+		return ""
+	}
 
-	return degrees + (minutes / 60.0) + (seconds / 3600.0)
+	utf16Data := make([]uint16, charCount)
+	for i := 0; i < charCount; i++ {
+		utf16Data[i] = binary.LittleEndian.Uint16(raw[i*2 : i*2+2])
+	}
+
+	// Decode UTF-16 to UTF-8 string
+	runes := utf16.Decode(utf16Data)
+	result := string(runes)
+
+	// Trim null terminators and any trailing whitespace
+	result = strings.TrimRight(result, "\x00")
+	return strings.TrimSpace(result)
 }
 
+// DecodeUserComment strips the 8-byte character code prefix from a UserComment value
+func DecodeUserComment(raw []byte) string {
+	if len(raw) <= 8 {
+		return ""
+	}
+	return strings.TrimRight(string(raw[8:]), "\x00")
+}
+
+// FormatVersion converts a 4 byte ASCII version such as "0232" to "2.32"
+func FormatVersion(raw []byte) string {
+	if len(raw) != 4 {
+		return ""
+	}
+	return fmt.Sprintf("%c.%c%c", raw[1], raw[2], raw[3])
+}
+
+// DecodeXMPMeta decodes an XMP packet, see the package level DecodeXMPMeta. Errors are reported to the
+// extractor's context and the partially decoded packet returned.
+func (e *ValueExtractor) DecodeXMPMeta(inXml []byte) XmpMeta {
+	xmp, err := DecodeXMPMeta(inXml)
+	if err != nil {
+		e.Context.Warn(DiagDecodeFailed, "XMP", -1, err.Error())
+	}
+	return xmp
+}
+
+// extractRawXMLAttribute extracts an attribute value without XML entity decoding
+func extractRawXMLAttribute(xmlStr, attrName string) string {
+	// Look for the attribute pattern with optional namespace prefix
+	// Pattern: (namespace:)?attrName="value"
+	pattern := `[a-zA-Z]*:?` + attrName + `="([^"]*)"`
+
+	re := regexp.MustCompile(pattern)
+	matches := re.FindStringSubmatch(xmlStr)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// bytesAt returns length bytes at offset, or nil when they are out of range
+func (e *ValueExtractor) bytesAt(offset, length int) []byte {
+	raw, err := e.source().Slice(offset, length)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// rationalAt reads the RATIONAL or SRATIONAL at offset, returning 0 when it is out of range or has a
+// zero denominator
+func (e *ValueExtractor) rationalAt(offset int, signed bool) float64 {
+	raw := e.bytesAt(offset, 8)
+	if raw == nil {
+		return 0
+	}
+	if signed {
+		return SRational{int32(e.Endian.Uint32(raw)), int32(e.Endian.Uint32(raw[4:]))}.Float()
+	}
+	return Rational{e.Endian.Uint32(raw), e.Endian.Uint32(raw[4:])}.Float()
+}
+
+// GetString reads entry as a NUL padded string, inline at entryOffset when it fits
+//
+// Deprecated: use Decode and TagValue.String.
 func (e *ValueExtractor) GetString(entry IFDEntry, entryOffset int) string {
 	offset := e.TiffStart + int(entry.ValueOffset)
-	return e.getString(entryOffset, offset, int(entry.Count))
+	if entry.Count <= 4 {
+		offset = entryOffset + 8
+	}
+	return strings.TrimRight(string(e.bytesAt(offset, int(entry.Count))), "\x00")
 }
 
+// GetUint32 reads the inline LONG of the entry at entryOffset
+//
+// Deprecated: use Decode and TagValue.Int.
 func (e *ValueExtractor) GetUint32(entryOffset int) uint32 {
-	if entryOffset < 0 || entryOffset+12 > len(e.Data) {
+	raw := e.bytesAt(entryOffset+8, 4)
+	if raw == nil {
 		return 0
 	}
-	return e.Endian.Uint32(e.Data[entryOffset+8 : entryOffset+12])
+	return e.Endian.Uint32(raw)
 }
 
+// GetUint32Array reads count LONGs stored at the entry's value offset
+//
+// Deprecated: use Decode.
 func (e *ValueExtractor) GetUint32Array(entry IFDEntry, count int) []uint32 {
-	offset := e.TiffStart + int(entry.ValueOffset)
-
-	if offset < 0 || offset+(count*4) > len(e.Data) {
+	raw := e.bytesAt(e.TiffStart+int(entry.ValueOffset), count*4)
+	if raw == nil {
 		return nil
 	}
 
 	result := make([]uint32, count)
-	for i := 0; i < count; i++ {
-		result[i] = e.Endian.Uint32(e.Data[offset+(i*4) : offset+(i*4)+4])
+	for i := range result {
+		result[i] = e.Endian.Uint32(raw[i*4:])
 	}
 	return result
 }
 
+// GetUint16 reads the inline SHORT of the entry at entryOffset
+//
+// Deprecated: use Decode and TagValue.Int.
 func (e *ValueExtractor) GetUint16(entryOffset int) uint16 {
-	if entryOffset < 0 || entryOffset+10 > len(e.Data) {
+	raw := e.bytesAt(entryOffset+8, 2)
+	if raw == nil {
 		return 0
 	}
-	return e.Endian.Uint16(e.Data[entryOffset+8 : entryOffset+10])
+	return e.Endian.Uint16(raw)
 }
 
+// GetUint8 reads the inline BYTE of the entry at entryOffset
+//
+// Deprecated: use Decode and TagValue.Int.
 func (e *ValueExtractor) GetUint8(entryOffset int) uint8 {
-	if entryOffset < 0 || entryOffset+8 >= len(e.Data) {
+	raw := e.bytesAt(entryOffset+8, 1)
+	if raw == nil {
 		return 0
 	}
-	return e.Data[entryOffset+8]
+	return raw[0]
 }
 
+// GetUint8Array copies numSlices inline bytes of the entry at entryOffset
+//
+// Deprecated: use Decode and TagValue.Bytes.
 func (e *ValueExtractor) GetUint8Array(entryOffset, numSlices int) []uint8 {
 	val := make([]uint8, numSlices)
-	copy(val, e.Data[entryOffset+8:entryOffset+8+numSlices])
+	copy(val, e.bytesAt(entryOffset+8, numSlices))
 	return val
 }
 
+// GetRational reads the RATIONAL or SRATIONAL nestedOffset bytes into the entry's value
+//
+// Deprecated: use Decode and TagValue.Float.
 func (e *ValueExtractor) GetRational(entry IFDEntry, nestedOffset int, signed bool) float64 {
-	offset := e.TiffStart + int(entry.ValueOffset) + nestedOffset
-	return e.getRational(offset, signed)
+	return e.rationalAt(e.TiffStart+int(entry.ValueOffset)+nestedOffset, signed)
 }
 
+// GetRationalParts reads the numerator and denominator nestedOffset bytes into the entry's value
+//
+// Deprecated: use Decode.
 func (e *ValueExtractor) GetRationalParts(entry IFDEntry, nestedOffset int) (uint32, uint32) {
-	offset := e.TiffStart + int(entry.ValueOffset) + nestedOffset
-	return e.getRationalParts(offset)
+	raw := e.bytesAt(e.TiffStart+int(entry.ValueOffset)+nestedOffset, 8)
+	if raw == nil {
+		return 0, 0
+	}
+	return e.Endian.Uint32(raw), e.Endian.Uint32(raw[4:])
 }
 
+// GetGPSCoord converts a degrees, minutes and seconds GPS value to decimal degrees
+//
+// Deprecated: use Decode and TagValue.Float.
 func (e *ValueExtractor) GetGPSCoord(entry IFDEntry) float64 {
 	offset := e.TiffStart + int(entry.ValueOffset)
-	return e.getGPSCoordinate(offset)
+	return e.rationalAt(offset, false) + e.rationalAt(offset+8, false)/60.0 + e.rationalAt(offset+16, false)/3600.0
 }
 
+// GetByteArray copies the entry's value as Count bytes, inline at entryOffset when it fits
+//
+// Deprecated: use Decode and TagValue.Bytes.
 func (e *ValueExtractor) GetByteArray(entry IFDEntry, entryOffset int) []byte {
-	var offset int
+	offset := e.TiffStart + int(entry.ValueOffset)
 	if entry.Count <= 4 {
 		offset = entryOffset + 8
-	} else {
-		offset = e.TiffStart + int(entry.ValueOffset)
 	}
 
-	if offset < 0 || offset+int(entry.Count) > len(e.Data) {
+	raw := e.bytesAt(offset, int(entry.Count))
+	if raw == nil {
 		return nil
 	}
-
-	result := make([]byte, entry.Count)
-	copy(result, e.Data[offset:offset+int(entry.Count)])
-	return result
+	return append([]byte(nil), raw...)
 }
 
+// GetUserComment reads a UserComment without its character code prefix
+//
+// Deprecated: use Decode and DecodeUserComment.
 func (e *ValueExtractor) GetUserComment(entry IFDEntry, entryOffset int) string {
-	raw := e.GetByteArray(entry, entryOffset)
-	if len(raw) <= 8 {
-		return ""
-	}
-	// Skip the 8-byte character code prefix
-	return strings.TrimRight(string(raw[8:]), "\x00")
+	return DecodeUserComment(e.GetByteArray(entry, entryOffset))
 }
 
+// GetVersion formats an inline 4 byte version such as "0232" as "2.32"
+//
+// Deprecated: use Decode and FormatVersion.
 func (e *ValueExtractor) GetVersion(entry IFDEntry, entryOffset int) string {
-	if entry.Count != 4 || entryOffset+12 > len(e.Data) {
+	if entry.Count != 4 {
 		return ""
 	}
-	raw := e.Data[entryOffset+8 : entryOffset+12]
-	// Convert "0232" → "2.32"
-	return fmt.Sprintf("%c.%c%c", raw[1], raw[2], raw[3])
+	return FormatVersion(e.bytesAt(entryOffset+8, 4))
 }
 
+// GetCompositeImageCount reads the source and used image counts of CompositeImageCount
+//
+// Deprecated: use Decode and TagValue.Int.
 func (e *ValueExtractor) GetCompositeImageCount(entry IFDEntry, entryOffset int) (uint16, uint16) {
 	if entry.Count < 2 {
 		return 0, 0
 	}
 
-	var offset int
+	offset := e.TiffStart + int(entry.ValueOffset)
 	if entry.Count*2 <= 4 {
 		offset = entryOffset + 8
-	} else {
-		offset = e.TiffStart + int(entry.ValueOffset)
 	}
 
-	if offset+4 > len(e.Data) {
+	raw := e.bytesAt(offset, 4)
+	if raw == nil {
 		return 0, 0
 	}
-
-	sourceNum := e.Endian.Uint16(e.Data[offset : offset+2])
-	usedNum := e.Endian.Uint16(e.Data[offset+2 : offset+4])
-
-	return sourceNum, usedNum
+	return e.Endian.Uint16(raw), e.Endian.Uint16(raw[2:])
 }
 
+// GetUTF16LEString reads one of the UTF-16LE Windows XP* tags
+//
+// Deprecated: use Decode and DecodeUTF16LE.
 func (e *ValueExtractor) GetUTF16LEString(entry IFDEntry, entryOffset int) string {
-	var offset int
+	offset := e.TiffStart + int(entry.ValueOffset)
 	if entry.Count*2 <= 4 {
 		offset = entryOffset + 8
-	} else {
-		offset = e.TiffStart + int(entry.ValueOffset)
-	}
-
-	if offset < 0 || offset+int(entry.Count) > len(e.Data) {
-		return ""
-	}
-
-	// Convert byte count to uint16 count
-	charCount := int(entry.Count) / 2
-	if charCount == 0 {
-		return ""
-	}
-
-	// Read UTF-16LE encoded data
-	utf16Data := make([]uint16, charCount)
-	for i := 0; i < charCount; i++ {
-		if offset+i*2+2 > len(e.Data) {
-			break
-		}
-		utf16Data[i] = binary.LittleEndian.Uint16(e.Data[offset+i*2 : offset+i*2+2])
-	}
-
-	// Decode UTF-16 to UTF-8 string
-	runes := utf16.Decode(utf16Data)
-	result := string(runes)
-
-	// Trim null terminators and any trailing whitespace
-	result = strings.TrimRight(result, "\x00")
-	return strings.TrimSpace(result)
-}
-
-func (e *ValueExtractor) DecodeXMPMeta(inXml []byte) XmpMeta {
-	var xmp XmpMeta
-
-	err := xml.Unmarshal(inXml, &xmp)
-	if err != nil {
-		slog.Error("Cannot unmarshal XMP", "error", err)
-		return xmp
-	}
-
-	// First, extract HdrPlusMakernote attribute BEFORE XML parsing
-	// to avoid XML entity decoding corrupting the base64 data
-	xmp.RDF.Description.HdrPlusMakerNote = extractRawXMLAttribute(string(inXml), "HdrPlusMakernote")
-
-	return xmp
-}
-
-// extractRawXMLAttribute extracts an attribute value without XML entity decoding
-func extractRawXMLAttribute(xmlStr, attrName string) string {
-	// Look for the attribute pattern with optional namespace prefix
-	// Pattern: (namespace:)?attrName="value"
-	pattern := `[a-zA-Z]*:?` + attrName + `="([^"]*)"`
-
-	re := regexp.MustCompile(pattern)
-	matches := re.FindStringSubmatch(xmlStr)
-	if len(matches) > 1 {
-		return matches[1]
 	}
-
-	return ""
+	return DecodeUTF16LE(e.bytesAt(offset, int(entry.Count)))
 }
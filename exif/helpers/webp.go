@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// VP8X feature flags
+const (
+	VP8XFlagAnimation = 0x02
+	VP8XFlagXMP       = 0x04
+	VP8XFlagExif      = 0x08
+	VP8XFlagAlpha     = 0x10
+	VP8XFlagICC       = 0x20
+)
+
+// WebPMetadata Metadata chunks and canvas information collected from a WebP RIFF container
+type WebPMetadata struct {
+	Extended     bool
+	Flags        uint8
+	CanvasWidth  int
+	CanvasHeight int
+	LoopCount    int
+	FrameCount   int
+	// Exif holds the EXIF chunk payload, which may or may not start with "Exif\0\0"
+	Exif []byte
+	// ExifOffset is the file offset of the EXIF chunk payload
+	ExifOffset int
+	XMP        string
+}
+
+// IsWebP checks for a RIFF header with the WEBP form type
+func IsWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// ReadWebP walks the RIFF chunks of a WebP file, reading VP8X canvas information and the EXIF and XMP
+// chunks. Image data is skipped over, only the few header bytes needed for the dimensions are read.
+func ReadWebP(src *Source) (*WebPMetadata, error) {
+	head, err := src.Slice(0, min(src.Size(), 12))
+	if err != nil || !IsWebP(head) {
+		return nil, errors.New("file is not a WebP")
+	}
+
+	end := src.Size()
+	if riffSize := int(binary.LittleEndian.Uint32(head[4:8])); riffSize+8 < end && riffSize >= 4 {
+		end = riffSize + 8
+	}
+
+	meta := &WebPMetadata{ExifOffset: -1}
+	pos := 12
+	for pos+8 <= end {
+		header, err := src.Slice(pos, 8)
+		if err != nil {
+			return meta, err
+		}
+		fourCC := string(header[0:4])
+		size := int(binary.LittleEndian.Uint32(header[4:8]))
+		start := pos + 8
+		if size < 0 || size > end-start {
+			return meta, fmt.Errorf("%q chunk at offset %d overruns file", fourCC, pos)
+		}
+
+		// Bitstream chunks are only read as far as their dimensions, and animation frames not at all
+		var read int
+		switch fourCC {
+		case "VP8X", "ANIM", "EXIF", "XMP ":
+			read = size
+		case "VP8 ":
+			read = min(size, 10)
+		case "VP8L":
+			read = min(size, 5)
+		}
+		chunk, err := src.Slice(start, read)
+		if err != nil {
+			return meta, err
+		}
+
+		switch fourCC {
+		case "VP8X":
+			if size < 10 {
+				return meta, errors.New("VP8X chunk too short")
+			}
+			meta.Extended = true
+			meta.Flags = chunk[0]
+			meta.CanvasWidth = int(uint24LE(chunk[4:7])) + 1
+			meta.CanvasHeight = int(uint24LE(chunk[7:10])) + 1
+		case "VP8 ":
+			// Lossy bitstream: 3 byte frame tag, 0x9d012a start code, then 14 bit dimensions
+			if meta.CanvasWidth == 0 && size >= 10 && chunk[3] == 0x9d && chunk[4] == 0x01 && chunk[5] == 0x2a {
+				meta.CanvasWidth = int(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3fff)
+				meta.CanvasHeight = int(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3fff)
+			}
+		case "VP8L":
+			// Lossless bitstream: 0x2f signature, then 14 bit width-1 and height-1
+			if meta.CanvasWidth == 0 && size >= 5 && chunk[0] == 0x2f {
+				bits := binary.LittleEndian.Uint32(chunk[1:5])
+				meta.CanvasWidth = int(bits&0x3fff) + 1
+				meta.CanvasHeight = int(bits>>14&0x3fff) + 1
+			}
+		case "ANIM":
+			if size >= 6 {
+				meta.LoopCount = int(binary.LittleEndian.Uint16(chunk[4:6]))
+			}
+		case "ANMF":
+			meta.FrameCount++
+		case "EXIF":
+			meta.Exif = chunk
+			meta.ExifOffset = start
+		case "XMP ":
+			meta.XMP = string(chunk)
+		}
+
+		// Chunks are padded to an even length
+		pos = start + size + size&1
+	}
+
+	return meta, nil
+}
+
+func uint24LE(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -1,10 +1,10 @@
 package helpers
 
 import (
+	"encoding/binary"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"log/slog"
 	"regexp"
 	"strings"
 )
@@ -15,6 +15,24 @@ type ContainerItem struct {
 	Length   int    `xml:"Length,attr,omitempty"`
 }
 
+// DroneDJIXMP The drone-dji attributes DJI aircraft write on rdf:Description, kept as the signed
+// decimal text they are stored as
+type DroneDJIXMP struct {
+	AbsoluteAltitude      string `xml:"http://www.dji.com/drone-dji/1.0/ AbsoluteAltitude,attr"`
+	RelativeAltitude      string `xml:"http://www.dji.com/drone-dji/1.0/ RelativeAltitude,attr"`
+	GimbalYawDegree       string `xml:"http://www.dji.com/drone-dji/1.0/ GimbalYawDegree,attr"`
+	GimbalPitchDegree     string `xml:"http://www.dji.com/drone-dji/1.0/ GimbalPitchDegree,attr"`
+	GimbalRollDegree      string `xml:"http://www.dji.com/drone-dji/1.0/ GimbalRollDegree,attr"`
+	FlightYawDegree       string `xml:"http://www.dji.com/drone-dji/1.0/ FlightYawDegree,attr"`
+	FlightPitchDegree     string `xml:"http://www.dji.com/drone-dji/1.0/ FlightPitchDegree,attr"`
+	FlightRollDegree      string `xml:"http://www.dji.com/drone-dji/1.0/ FlightRollDegree,attr"`
+	FlightXSpeed          string `xml:"http://www.dji.com/drone-dji/1.0/ FlightXSpeed,attr"`
+	FlightYSpeed          string `xml:"http://www.dji.com/drone-dji/1.0/ FlightYSpeed,attr"`
+	FlightZSpeed          string `xml:"http://www.dji.com/drone-dji/1.0/ FlightZSpeed,attr"`
+	RtkFlag               string `xml:"http://www.dji.com/drone-dji/1.0/ RtkFlag,attr"`
+	CalibratedFocalLength string `xml:"http://www.dji.com/drone-dji/1.0/ CalibratedFocalLength,attr"`
+}
+
 type XmpMeta struct {
 	XMLName xml.Name `xml:"xmpmeta"`
 	RDF     struct {
@@ -35,70 +53,153 @@ type XmpMeta struct {
 					} `xml:"li"`
 				} `xml:"Seq"`
 			} `xml:"Directory"`
+			DroneDJIXMP
 		} `xml:"Description"`
 	} `xml:"RDF"`
 }
 
+// DecodeXMPMeta decodes the parts of an XMP packet we understand, regardless of which container it came from
+func DecodeXMPMeta(inXml []byte) (XmpMeta, error) {
+	var xmp XmpMeta
+
+	err := xml.Unmarshal(inXml, &xmp)
+	if err != nil {
+		return xmp, fmt.Errorf("cannot unmarshal XMP: %w", err)
+	}
+
+	// First, extract HdrPlusMakernote attribute BEFORE XML parsing
+	// to avoid XML entity decoding corrupting the base64 data
+	xmp.RDF.Description.HdrPlusMakerNote = extractRawXMLAttribute(string(inXml), "HdrPlusMakernote")
+
+	return xmp, nil
+}
+
+// ExtractXMPData returns the XMP packet of a JPEG file held in memory
 func ExtractXMPData(data []byte) (string, error) {
-	xmpHeader := "http://ns.adobe.com/xap/1.0/\x00"
-	for i := 0; i < len(data)-len(xmpHeader); i++ {
-		start := 0
-		if string(data[i:i+len(xmpHeader)]) == xmpHeader {
-			start = i
-		} else {
-			continue
-		}
-		end := start
-		for end < len(data)-11 {
-			if string(data[end:end+12]) == "</x:xmpmeta>" {
-				end += 12
-				return strings.TrimLeft(string(data[start:end]), xmpHeader), nil
-			}
-			end++
-		}
+	return XMPFromSource(NewBytesSource(data))
+}
+
+// XMPFromSource walks the marker chain of a JPEG file and returns its XMP packet
+func XMPFromSource(src *Source) (string, error) {
+	segments, err := ReadJPEGSegments(src)
+	if len(segments) == 0 {
+		return "", err
+	}
+	return XMPFromSegments(src, segments)
+}
+
+// XMPFromSegments returns the XMP packet of a JPEG whose marker chain has already been walked
+func XMPFromSegments(src *Source, segments []JPEGSegment) (string, error) {
+	segment, ok := FindJPEGSegment(src, segments, MarkerAPP1, XMPSignature)
+	if !ok {
+		return "", errors.New("XMP block not found")
+	}
+
+	payload := segment.Payload(src)
+	if len(payload) < len(XMPSignature) {
+		return "", errors.New("cannot read XMP block")
+	}
+
+	packet := string(payload[len(XMPSignature):])
+	end := strings.Index(packet, "</x:xmpmeta>")
+	if end == -1 {
 		return "", errors.New("XMP end tag not found")
 	}
-	return "", errors.New("XMP block not found")
+
+	return packet[:end+len("</x:xmpmeta>")], nil
 }
 
+// ExtractExtXMPData reassembles the extended XMP packet identified by extId from a JPEG file held in
+// memory
 func ExtractExtXMPData(data []byte, extId string) (string, error) {
-	extHeader := fmt.Sprintf("http://ns.adobe.com/xmp/extension/\x00%s\x00", extId)
-	for i := 0; i < len(data)-len(extHeader); i++ {
-		start := 0
-		if string(data[i:i+len(extHeader)]) == extHeader {
-			start = i
-		} else {
+	return ExtXMPFromSource(NewBytesSource(data), extId, nil)
+}
+
+// ExtXMPFromSource walks the marker chain of a JPEG file and reassembles its extended XMP packet
+func ExtXMPFromSource(src *Source, extId string, ctx *ParseContext) (string, error) {
+	segments, err := ReadJPEGSegments(src)
+	if len(segments) == 0 {
+		return "", err
+	}
+	return ExtXMPFromSegments(src, segments, extId, ctx)
+}
+
+// ExtXMPFromSegments reassembles the extended XMP packet identified by extId from the segments of a
+// JPEG whose marker chain has already been walked. Each APP1 chunk carries the GUID, the full packet
+// length and the chunk's offset within the packet, followed by the chunk data.
+func ExtXMPFromSegments(src *Source, segments []JPEGSegment, extId string, ctx *ParseContext) (string, error) {
+	// signature + 32 byte GUID + 4 byte full length + 4 byte offset
+	const chunkHeaderSize = len(ExtXMPSignature) + 32 + 4 + 4
+
+	type extChunk struct {
+		segmentOffset int
+		offset        int
+		data          []byte
+	}
+
+	// Collect the chunks first, so the packet is sized by the data actually present rather than by
+	// the length the file declares
+	var chunks []extChunk
+	fullLength, stored := 0, 0
+	for _, segment := range segments {
+		if segment.Marker != MarkerAPP1 || !segment.HasSignature(src, ExtXMPSignature) {
 			continue
 		}
-		end := start
-		for end < len(data)-11 {
-			if string(data[end:end+12]) == "</x:xmpmeta>" {
-				end += 12
-				// Skip past the header by moving start position
-				start = start + len(extHeader)
-				xmlString := string(data[start:end])
-
-				// Find the actual XML start
-				tagStart := strings.Index(xmlString, "<x:xmpmeta")
-				if tagStart != -1 {
-					xmlString = xmlString[tagStart:]
-				}
 
-				var b strings.Builder
-				for _, c := range xmlString {
-					if c == '\uFFFD' {
-						continue
-					}
-					b.WriteRune(c)
-				}
+		payload := segment.Payload(src)
+		if len(payload) < chunkHeaderSize {
+			continue
+		}
 
-				return SanitizeXMLString(b.String()), nil
-			}
-			end++
+		guid := string(payload[len(ExtXMPSignature) : len(ExtXMPSignature)+32])
+		if guid != extId {
+			continue
 		}
+
+		if chunks == nil {
+			fullLength = int(binary.BigEndian.Uint32(payload[len(ExtXMPSignature)+32:]))
+		}
+		chunk := extChunk{
+			segmentOffset: segment.Offset,
+			offset:        int(binary.BigEndian.Uint32(payload[len(ExtXMPSignature)+36:])),
+			data:          payload[chunkHeaderSize:],
+		}
+		chunks = append(chunks, chunk)
+		stored += len(chunk.data)
+	}
+
+	if len(chunks) == 0 {
+		return "", errors.New("extended XMP data not found")
+	}
+	// Every byte of the packet is carried by a chunk, so a larger declared length is a lie
+	if fullLength > stored {
+		return "", fmt.Errorf("extended XMP length %d exceeds the %d bytes in its chunks", fullLength, stored)
+	}
+
+	packet := make([]byte, fullLength)
+	found := false
+	for _, chunk := range chunks {
+		if chunk.offset > len(packet) || len(chunk.data) > len(packet)-chunk.offset {
+			ctx.Warn(DiagOutOfRange, "XMP", chunk.segmentOffset, "extended XMP chunk overruns declared length",
+				"chunkOffset", chunk.offset, "chunkLength", len(chunk.data), "fullLength", len(packet))
+			continue
+		}
+		copy(packet[chunk.offset:], chunk.data)
+		found = true
+	}
+
+	if !found {
+		return "", errors.New("extended XMP data not found")
+	}
+
+	xmlString := string(packet)
+	tagStart := strings.Index(xmlString, "<x:xmpmeta")
+	tagEnd := strings.LastIndex(xmlString, "</x:xmpmeta>")
+	if tagStart == -1 || tagEnd < tagStart {
 		return "", errors.New("XMP end tag not found")
 	}
-	return "", errors.New("extended XMP data not found")
+
+	return SanitizeXMLString(xmlString[tagStart : tagEnd+len("</x:xmpmeta>")]), nil
 }
 
 func SanitizeXMLString(s string) string {
@@ -123,7 +224,14 @@ func SanitizeXMLString(s string) string {
 	return result.String()
 }
 
+// SanitizeBase64String removes every character that is not part of the base64 alphabet, logging
+// what was removed to the default logger
 func SanitizeBase64String(s string) string {
+	return SanitizeBase64StringWithContext(s, nil)
+}
+
+// SanitizeBase64StringWithContext is SanitizeBase64String logging through ctx
+func SanitizeBase64StringWithContext(s string, ctx *ParseContext) string {
 	var result strings.Builder
 	result.Grow(len(s))
 
@@ -215,7 +323,7 @@ func SanitizeBase64String(s string) string {
 			countSummary = append(countSummary, fmt.Sprintf("%s:%d", name, count))
 		}
 
-		slog.Debug("Removed invalid characters from base64",
+		ctx.Log().Debug("Removed invalid characters from base64",
 			"totalRemoved", len(removedChars),
 			"first10", strings.Join(charDetails, ", "),
 			"summary", strings.Join(countSummary, ", "))
@@ -231,7 +339,7 @@ func SanitizeBase64String(s string) string {
 	mod := len(cleaned) % 4
 	if mod > 0 {
 		paddingNeeded := 4 - mod
-		slog.Debug("Fixing base64 padding",
+		ctx.Log().Debug("Fixing base64 padding",
 			"originalLength", len(cleaned),
 			"mod4", mod,
 			"paddingAdded", paddingNeeded)
@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// extXMPChunk builds the payload of an extended XMP APP1 segment
+func extXMPChunk(guid string, fullLength, offset uint32, data string) string {
+	header := binary.BigEndian.AppendUint32(nil, fullLength)
+	header = binary.BigEndian.AppendUint32(header, offset)
+	return ExtXMPSignature + guid + string(header) + data
+}
+
+func TestExtXMPFromSource(t *testing.T) {
+	const packet = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF/></x:xmpmeta>`
+	const otherGUID = "FEDCBA9876543210FEDCBA9876543210"
+	n := uint32(len(packet))
+
+	tests := []struct {
+		name     string
+		segments []string
+		want     string
+		wantErr  bool
+		warnings int
+	}{
+		{"single chunk", []string{extXMPChunk(fuzzGUID, n, 0, packet)}, packet, false, 0},
+		{"chunks out of order", []string{
+			extXMPChunk(fuzzGUID, n, 20, packet[20:]),
+			extXMPChunk(fuzzGUID, n, 0, packet[:20]),
+		}, packet, false, 0},
+		{"other packets ignored", []string{
+			extXMPChunk(otherGUID, n, 0, "<x:xmpmeta>other</x:xmpmeta>"),
+			extXMPChunk(fuzzGUID, n, 0, packet),
+		}, packet, false, 0},
+		{"chunk overruns length", []string{
+			extXMPChunk(fuzzGUID, n, 0, packet),
+			extXMPChunk(fuzzGUID, n, n-2, "overrun"),
+		}, packet, false, 1},
+		// A huge declared length is rejected before anything is allocated for it
+		{"length beyond chunks", []string{extXMPChunk(fuzzGUID, 0xffffffff, 0, packet)}, "", true, 0},
+		{"not found", []string{extXMPChunk(otherGUID, n, 0, packet)}, "", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &ParseContext{}
+			got, err := ExtXMPFromSource(NewBytesSource(xmpJPEG(tt.segments...)), fuzzGUID, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtXMPFromSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtXMPFromSource() = %q, want %q", got, tt.want)
+			}
+			if len(ctx.Diagnostics) != tt.warnings {
+				t.Errorf("diagnostics = %v, want %d", ctx.Diagnostics, tt.warnings)
+			}
+		})
+	}
+}
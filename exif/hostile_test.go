@@ -0,0 +1,123 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// extractNoPanic runs the full extraction with tag recording on and fails the test on any panic
+func extractNoPanic(t *testing.T, name string, data []byte) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s: panic: %v\n%s", name, r, debug.Stack())
+		}
+	}()
+
+	metadata, err := ExtractExifDataWithOptions(data, Options{AllTags: true, Logger: slog.New(slog.DiscardHandler)})
+	if metadata == nil && err == nil {
+		t.Fatalf("%s: no metadata and no error", name)
+	}
+}
+
+// hostileCorpus loads every file in testdata/hostile along with the metadata head of example.jpg
+func hostileCorpus(t *testing.T) map[string][]byte {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "hostile", "*"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("cannot find hostile corpus: %v", err)
+	}
+
+	corpus := map[string][]byte{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corpus[filepath.Base(path)] = data
+	}
+
+	// The APP segments of the sample photo hold EXIF, XMP and an HDR+ MakerNote
+	example, err := os.ReadFile(filepath.Join("..", "example.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpus["example.jpg"] = example[:98<<10]
+
+	return corpus
+}
+
+func TestHostileCorpus(t *testing.T) {
+	for name, data := range hostileCorpus(t) {
+		t.Run(name, func(t *testing.T) {
+			extractNoPanic(t, name, data)
+		})
+	}
+}
+
+func TestTruncatedInputs(t *testing.T) {
+	for name, data := range hostileCorpus(t) {
+		// Every length for small files, otherwise enough cut points to land in each structure
+		step := max(1, len(data)/4096)
+		for n := 0; n < len(data); n += step {
+			extractNoPanic(t, fmt.Sprintf("%s[:%d]", name, n), data[:n])
+		}
+	}
+}
+
+func TestCorruptedInputs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	values := []byte{0x00, 0x01, 0x7f, 0x80, 0xfe, 0xff}
+
+	for name, data := range hostileCorpus(t) {
+		if len(data) == 0 {
+			continue
+		}
+
+		// Corruption concentrates in the first few KB, where the headers and IFDs live
+		window := min(len(data), 8<<10)
+		for i := 0; i < 200; i++ {
+			corrupt := append([]byte(nil), data...)
+			for j := 0; j < 1+rng.Intn(8); j++ {
+				corrupt[rng.Intn(window)] = values[rng.Intn(len(values))]
+			}
+			extractNoPanic(t, fmt.Sprintf("%s corruption %d", name, i), corrupt)
+		}
+	}
+}
+
+func TestIFDLoopsAndOverlaps(t *testing.T) {
+	// The Exif pointer leads back to IFD0 and the Make value points into IFD0's own entries
+	tiff := buildTIFF(binary.BigEndian, func(pointer func(int) []byte) [][]tiffEntry {
+		return [][]tiffEntry{
+			{
+				{0x010f, 2, 8, pointer(0)},
+				{0x8769, 4, 1, pointer(0)},
+			},
+		}
+	})
+
+	metadata, err := ExtractExifDataWithOptions(tiff, Options{Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := map[string]int{}
+	for _, diagnostic := range metadata.Warnings {
+		codes[diagnostic.Code]++
+	}
+	if codes[helpers.DiagIFDLoop] != 1 {
+		t.Errorf("got %d ifd_loop diagnostics, want 1: %v", codes[helpers.DiagIFDLoop], metadata.Warnings)
+	}
+	if codes[helpers.DiagOverlap] != 1 {
+		t.Errorf("got %d overlap diagnostics, want 1: %v", codes[helpers.DiagOverlap], metadata.Warnings)
+	}
+}
@@ -0,0 +1,34 @@
+package exif
+
+import (
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Interoperability IFD Tags
+const (
+	InteropIndex           helpers.Tag = 0x0001
+	InteropVersion         helpers.Tag = 0x0002
+	RelatedImageFileFormat helpers.Tag = 0x1000
+	RelatedImageWidth      helpers.Tag = 0x1001
+	RelatedImageHeight     helpers.Tag = 0x1002
+)
+
+func ExtractInteropIFD(interopIfdOffset int, metadata *helpers.PhotoExifEvidence, helper *helpers.ValueExtractor) {
+	_, err := helper.WalkIFD("InteropIFD", interopIfdOffset, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case InteropIndex:
+			metadata.Image.InteropIndex = helpers.ParseInteropIndex(value.String())
+		case InteropVersion:
+			metadata.Image.InteropVersion = helpers.FormatVersion(value.Bytes())
+		case RelatedImageFileFormat:
+			metadata.Image.RelatedImageFileFormat = value.String()
+		case RelatedImageWidth:
+			metadata.Image.RelatedImageWidth = int(value.Int(0))
+		case RelatedImageHeight:
+			metadata.Image.RelatedImageHeight = int(value.Int(0))
+		}
+	})
+	if err != nil {
+		helper.Context.IFDError("InteropIFD", interopIfdOffset, err)
+	}
+}
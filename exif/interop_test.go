@@ -0,0 +1,58 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestExtractInteropIFD(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		interop := []tiffEntry{
+			{0x0001, 2, 4, []byte("R03\x00")},
+			{0x0002, 7, 4, []byte("0100")},
+			{0x1000, 2, 10, []byte("Exif JPEG\x00")},
+			{0x1001, 4, 1, order.AppendUint32(nil, 4000)},
+			{0x1002, 3, 1, order.AppendUint16(nil, 3000)},
+		}
+		want := helpers.ImageProperties{
+			InteropIndex:           "R03 - DCF option file (Adobe RGB)",
+			InteropVersion:         "1.00",
+			RelatedImageFileFormat: "Exif JPEG",
+			RelatedImageWidth:      4000,
+			RelatedImageHeight:     3000,
+		}
+
+		tests := []struct {
+			name     string
+			pointer  func(pointer func(int) []byte) []byte
+			want     helpers.ImageProperties
+			warnings int
+		}{
+			{"decoded", func(pointer func(int) []byte) []byte { return pointer(4) }, want, 0},
+			// The rest of the file is still decoded when the pointer leads nowhere
+			{"out of range", func(func(int) []byte) []byte { return order.AppendUint32(nil, 1<<20) }, helpers.ImageProperties{}, 1},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%v/%s", order, tt.name), func(t *testing.T) {
+				tiff := buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+					ifd0, exif, gps := evidenceIFDs(order, pointer, 2)
+					exif = append(exif, tiffEntry{0xa005, 4, 1, tt.pointer(pointer)})
+					return [][]tiffEntry{ifd0, {}, exif, gps, interop}
+				})
+
+				metadata, err := ExtractExifDataWithOptions(wrapJPEG(tiff), Options{Logger: slog.New(slog.DiscardHandler)})
+				checkEvidence(t, metadata, err)
+				if fmt.Sprint(metadata.Image) != fmt.Sprint(tt.want) {
+					t.Errorf("Image = %+v, want %+v", metadata.Image, tt.want)
+				}
+				if len(metadata.Warnings) != tt.warnings {
+					t.Errorf("warnings = %v, want %d", metadata.Warnings, tt.warnings)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package exif
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+	"github.com/ZanyLeonic/exif-reader/exif/makernotes"
+	"github.com/ZanyLeonic/exif-reader/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// findExifSegment returns the APP1 segment carrying the "Exif\0\0" signature, skipping any other APP1
+// segments such as XMP
+func findExifSegment(src *helpers.Source, segments []helpers.JPEGSegment, ctx *helpers.ParseContext) (helpers.JPEGSegment, error) {
+	segment, ok := helpers.FindJPEGSegment(src, segments, helpers.MarkerAPP1, helpers.ExifSignature)
+	if !ok {
+		return helpers.JPEGSegment{}, errors.New("cannot find EXIF block")
+	}
+
+	ctx.Log().Debug("Found APP1 segment", "offset", segment.Offset, "length", segment.Length)
+	return segment, nil
+}
+
+func extractJPEG(src *helpers.Source, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, error) {
+	// The marker chain is walked once, and the EXIF and XMP lookups share the index
+	segments, err := helpers.ReadJPEGSegments(src)
+	if len(segments) == 0 {
+		return nil, err
+	}
+	if err != nil {
+		ctx.Warn(helpers.DiagTruncated, "JPEG", -1, "JPEG marker chain is incomplete, using segments found so far: "+err.Error(), "segments", len(segments))
+	}
+
+	// Determine if we are working with a JPEG with EXIF data
+	segment, err := findExifSegment(src, segments, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// TIFF header follows the "Exif\0\0" signature. Fill bytes may come before the marker, so this
+	// counts from the payload rather than the marker.
+	metadata, helper, err := decodeTIFFSection(src, segment.DataOffset, segment.Length-2, segment.DataOffset+len(helpers.ExifSignature), ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var xmp helpers.XmpMeta
+	xmpPacket, xmpErr := helpers.XMPFromSegments(src, segments)
+	if xmpErr == nil {
+		xmp = applyXMP(ctx, metadata, xmpPacket)
+	}
+
+	// Samsung cameras append a directory of extra blocks after the image data
+	trailer, err := makernotes.ParseSamsungTrailer(src, ctx)
+	if err != nil {
+		ctx.Warn(helpers.DiagDecodeFailed, "SamsungTrailer", -1, "cannot parse Samsung trailer: "+err.Error())
+	} else if trailer != nil {
+		metadata.Authenticity.SamsungTrailer = *trailer
+	}
+
+	// Photo doesn't need extra processing for MakerNote
+	if !strings.HasPrefix(metadata.Processing.Software, "HDR+") {
+		return metadata, nil
+	}
+
+	if xmpErr != nil {
+		ctx.Error(helpers.DiagDecodeFailed, "XMP", -1, "cannot extract XMP metadata: "+xmpErr.Error())
+		return metadata, xmpErr
+	}
+
+	if xmp.RDF.Description.HasExtendedXMP == "" {
+		return metadata, nil
+	}
+
+	output, err := helpers.ExtXMPFromSegments(src, segments, xmp.RDF.Description.HasExtendedXMP, ctx)
+	if err != nil {
+		ctx.Error(helpers.DiagDecodeFailed, "XMP", -1, "cannot extract extended XMP metadata: "+err.Error())
+		return metadata, err
+	}
+
+	extXmp := helper.DecodeXMPMeta([]byte(output))
+	cleanBase64 := helpers.SanitizeBase64StringWithContext(extXmp.RDF.Description.HdrPlusMakerNote, ctx)
+
+	ctx.Log().Debug("Base64 lengths", "raw", len(extXmp.RDF.Description.HdrPlusMakerNote), "cleaned", len(cleanBase64))
+
+	// Try standard encoding first
+	encrypted, err := base64.StdEncoding.DecodeString(cleanBase64)
+	if err != nil {
+		ctx.Log().Debug("StdEncoding failed, trying RawStdEncoding", "error", err)
+		// Try without padding
+		encrypted, err = base64.RawStdEncoding.DecodeString(cleanBase64)
+		if err != nil {
+			ctx.Error(helpers.DiagDecodeFailed, "MakerNotes", -1, "cannot base64 decode HDRPlusMakerNote: "+err.Error(), "cleanedLength", len(cleanBase64))
+			return metadata, err
+		}
+	}
+
+	// "HDRP", a version byte, then the encrypted payload
+	if len(encrypted) > 5 && string(encrypted[0:4]) == "HDRP" {
+		ctx.Log().Debug("Found Google's HDRPlus header")
+
+		decrypted, err := makernotes.DecryptHDRPBytes(encrypted[5:])
+		if err != nil {
+			return metadata, err
+		}
+
+		protoBytes, err := makernotes.ReadGzipContentWithContext(decrypted, ctx)
+		if err != nil {
+			return metadata, err
+		}
+
+		// Try to parse the protobuf, even if truncated. Unknown fields are kept, to be reported raw.
+		hdrPlusNotes := pb.GoogleHDRPlusMakerNote{}
+		err = proto.Unmarshal(protoBytes, &hdrPlusNotes)
+		if err != nil {
+			// Like ExifTool, treat protobuf parse errors as warnings
+			// The data is likely truncated, but we can still extract other EXIF data
+			ctx.Warn(helpers.DiagTruncated, "MakerNotes", -1, "HDR+ protobuf parsing incomplete, data may be truncated or corrupted: "+err.Error(), "dataSize", len(protoBytes))
+		} else {
+			ctx.Log().Debug("Successfully parsed HDR Plus MakerNotes", "hasData", hdrPlusNotes.ProtoReflect().IsValid())
+		}
+
+		// Populate the MakerNote data in the metadata struct
+		metadata.Authenticity.MakerNote = makernotes.ConvertHDRPlusToMakerNote(&hdrPlusNotes, encrypted)
+		if hdrPlus, ok := metadata.Authenticity.MakerNote.Parsed.(*makernotes.GoogleHDRPlusMakerNote); ok {
+			checkHDRPlusCreateDate(ctx, metadata.Temporal, hdrPlus.CreateDate)
+		}
+	}
+
+	return metadata, nil
+}
+
+// hdrPlusDateTolerance allows for the HDR+ burst starting before the shutter press, and for
+// DateTimeOriginal having whole seconds
+const hdrPlusDateTolerance = 5 * time.Second
+
+// checkHDRPlusCreateDate compares the HDR+ capture time with DateTimeOriginal, which can only be
+// placed in time when OffsetTimeOriginal gives its time zone
+func checkHDRPlusCreateDate(ctx *helpers.ParseContext, temporal helpers.TemporalData, createDate time.Time) {
+	if createDate.IsZero() || temporal.DateCaptured.IsZero() || temporal.OffsetTimeOriginal == "" {
+		return
+	}
+	offset, err := time.Parse("-07:00", temporal.OffsetTimeOriginal)
+	if err != nil {
+		ctx.Log().Debug("Cannot parse OffsetTimeOriginal", "offset", temporal.OffsetTimeOriginal, "error", err)
+		return
+	}
+	_, seconds := offset.Zone()
+
+	// DateTimeOriginal is read as UTC, so removing the offset gives the instant it describes
+	captured := temporal.DateCaptured.Add(-time.Duration(seconds) * time.Second)
+	if difference := createDate.Sub(captured).Abs(); difference > hdrPlusDateTolerance {
+		ctx.Warn(helpers.DiagDateMismatch, "MakerNotes", -1, "HDR+ create date differs from DateTimeOriginal",
+			"createDate", createDate, "dateTimeOriginal", captured, "difference", difference)
+	}
+}
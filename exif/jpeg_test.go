@@ -0,0 +1,202 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+	"github.com/ZanyLeonic/exif-reader/exif/makernotes"
+)
+
+// evidenceTIFF builds a TIFF structure holding the Make, DateTimeOriginal and GPS position that
+// checkEvidence expects, with an empty IFD1
+func evidenceTIFF(order binary.AppendByteOrder) []byte {
+	return buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+		ifd0, exif, gps := evidenceIFDs(order, pointer, 2)
+		return [][]tiffEntry{ifd0, {}, exif, gps}
+	})
+}
+
+// evidenceIFDs returns the IFD0, Exif and GPS entries of evidenceTIFF, for a layout with the Exif IFD
+// at index exifIFD and the GPS IFD just after it
+func evidenceIFDs(order binary.AppendByteOrder, pointer func(int) []byte, exifIFD int) ([]tiffEntry, []tiffEntry, []tiffEntry) {
+	rational := func(v ...uint32) []byte {
+		var b []byte
+		for _, x := range v {
+			b = order.AppendUint32(b, x)
+			b = order.AppendUint32(b, 1)
+		}
+		return b
+	}
+
+	ifd0 := []tiffEntry{
+		{0x010f, 2, 6, []byte("Canon\x00")},
+		{0x8769, 4, 1, pointer(exifIFD)},
+		{0x8825, 4, 1, pointer(exifIFD + 1)},
+	}
+	exif := []tiffEntry{
+		{0x9003, 2, 20, []byte("2024:10:01 14:58:52\x00")},
+	}
+	gps := []tiffEntry{
+		{0x0001, 2, 2, []byte("N\x00")},
+		{0x0002, 5, 3, rational(51, 30, 15)},
+		{0x0003, 2, 2, []byte("W\x00")},
+		{0x0004, 5, 3, rational(0, 7, 40)},
+	}
+	return ifd0, exif, gps
+}
+
+// checkEvidence fails the test unless the fields of evidenceTIFF were decoded
+func checkEvidence(t *testing.T, metadata *helpers.PhotoExifEvidence, err error) {
+	t.Helper()
+	if metadata == nil {
+		t.Fatalf("no metadata: %v", err)
+	}
+	if metadata.Device.Make != "Canon" {
+		t.Errorf("Make = %q, want Canon", metadata.Device.Make)
+	}
+	if want := time.Date(2024, 10, 1, 14, 58, 52, 0, time.UTC); !metadata.Temporal.DateCaptured.Equal(want) {
+		t.Errorf("DateCaptured = %v, want %v", metadata.Temporal.DateCaptured, want)
+	}
+	if math.Abs(metadata.GPS.Latitude-51.504167) > 1e-6 || math.Abs(metadata.GPS.Longitude+0.127778) > 1e-6 {
+		t.Errorf("GPS = %v, %v, want 51.504167, -0.127778", metadata.GPS.Latitude, metadata.GPS.Longitude)
+	}
+}
+
+// jpegSegment encodes a marker segment with its length
+func jpegSegment(marker byte, payload string) []byte {
+	out := binary.BigEndian.AppendUint16([]byte{0xff, marker}, uint16(len(payload)+2))
+	return append(out, payload...)
+}
+
+func TestExtractJPEGSegments(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		exif := jpegSegment(helpers.MarkerAPP1, helpers.ExifSignature+string(evidenceTIFF(order)))
+
+		tests := []struct {
+			name     string
+			segments [][]byte
+		}{
+			{"exif only", [][]byte{exif}},
+			{"after JFIF and XMP", [][]byte{
+				jpegSegment(helpers.MarkerAPP0, "JFIF\x00\x01\x02"),
+				jpegSegment(helpers.MarkerAPP1, helpers.XMPSignature+"<x:xmpmeta></x:xmpmeta>"),
+				exif,
+			}},
+			// Scanning for the signature would find the one quoted in the comment first
+			{"signature in a comment", [][]byte{
+				jpegSegment(helpers.MarkerCOM, "\xff\xe1\x00\x10"+helpers.ExifSignature+"MM\x00\x2a\x00\x00\x00\x08"),
+				exif,
+			}},
+			{"fill bytes", [][]byte{{0xff, 0xff}, exif}},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%v/%s", order, tt.name), func(t *testing.T) {
+				data := []byte{0xff, helpers.MarkerSOI}
+				for _, segment := range tt.segments {
+					data = append(data, segment...)
+				}
+				data = append(data, 0xff, helpers.MarkerEOI)
+
+				metadata, err := ExtractExifData(data)
+				checkEvidence(t, metadata, err)
+			})
+		}
+	}
+}
+
+func TestHDRPlusCreateDate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "example.jpg"))
+	if err != nil {
+		t.Skip("example.jpg not available")
+	}
+
+	metadata, err := ExtractExifData(data)
+	if err != nil {
+		t.Fatalf("ExtractExifData() error = %v", err)
+	}
+	hdrPlus, ok := metadata.Authenticity.MakerNote.Parsed.(*makernotes.GoogleHDRPlusMakerNote)
+	if !ok {
+		t.Fatalf("parsed MakerNote is %T", metadata.Authenticity.MakerNote.Parsed)
+	}
+
+	// DateTimeOriginal is 14:58:52.085 at +01:00, and the burst was captured a moment later
+	if want := time.Date(2024, 10, 1, 13, 58, 53, 618906441, time.UTC); !hdrPlus.CreateDate.Equal(want) {
+		t.Errorf("CreateDate = %v, want %v", hdrPlus.CreateDate, want)
+	}
+	// The per-frame data lies outside the schema and is kept raw
+	if len(hdrPlus.UnknownFields) == 0 {
+		t.Error("UnknownFields is empty")
+	}
+	for _, warning := range metadata.Warnings {
+		if warning.Code == helpers.DiagDateMismatch {
+			t.Errorf("unexpected warning: %v", warning)
+		}
+	}
+}
+
+func TestCheckHDRPlusCreateDate(t *testing.T) {
+	temporal := helpers.TemporalData{
+		DateCaptured:       time.Date(2024, 10, 1, 14, 58, 52, 0, time.UTC),
+		OffsetTimeOriginal: "+01:00",
+	}
+	tests := []struct {
+		name       string
+		createDate time.Time
+		temporal   helpers.TemporalData
+		mismatch   bool
+	}{
+		{"matching", time.Date(2024, 10, 1, 13, 58, 53, 0, time.UTC), temporal, false},
+		{"an hour out", time.Date(2024, 10, 1, 14, 58, 53, 0, time.UTC), temporal, true},
+		// Without a time zone DateTimeOriginal cannot be compared
+		{"no offset", time.Date(2024, 10, 1, 14, 58, 53, 0, time.UTC), helpers.TemporalData{DateCaptured: temporal.DateCaptured}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &helpers.ParseContext{}
+			checkHDRPlusCreateDate(ctx, tt.temporal, tt.createDate)
+			if mismatch := len(ctx.Diagnostics) == 1 && ctx.Diagnostics[0].Code == helpers.DiagDateMismatch; mismatch != tt.mismatch {
+				t.Errorf("diagnostics = %v, want mismatch %v", ctx.Diagnostics, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestPointersStayInSection(t *testing.T) {
+	// makeAt builds a TIFF whose Make value is stored at TIFF offset offset
+	makeAt := func(offset uint32) []byte {
+		return buildTIFF(binary.BigEndian, func(func(int) []byte) [][]tiffEntry {
+			return [][]tiffEntry{{{0x010f, 2, 6, binary.BigEndian.AppendUint32(nil, offset)}}}
+		})
+	}
+	outside := "Canon\x00"
+
+	// Both TIFF structures start 12 bytes into the file, and the Make they point at lies in the
+	// segment or chunk that follows
+	tiffLength := len(makeAt(0))
+	jpeg := append([]byte{0xff, helpers.MarkerSOI}, jpegSegment(helpers.MarkerAPP1, helpers.ExifSignature+string(makeAt(uint32(tiffLength+4))))...)
+	jpeg = append(append(jpeg, jpegSegment(helpers.MarkerCOM, outside)...), 0xff, helpers.MarkerEOI)
+
+	webp := webpFile(helpers.VP8XFlagExif, riffChunk("EXIF", makeAt(uint32(tiffLength+tiffLength%2+8))), riffChunk("ICCP", []byte(outside)))
+
+	for name, data := range map[string][]byte{"jpeg": jpeg, "webp": webp} {
+		t.Run(name, func(t *testing.T) {
+			metadata, err := ExtractExifDataWithOptions(data, Options{Logger: slog.New(slog.DiscardHandler)})
+			if metadata == nil {
+				t.Fatalf("no metadata: %v", err)
+			}
+			if metadata.Device.Make != "" {
+				t.Errorf("Make = %q read from outside the EXIF data", metadata.Device.Make)
+			}
+			if len(metadata.Warnings) != 1 || metadata.Warnings[0].Code != helpers.DiagOutOfRange {
+				t.Errorf("Warnings = %v, want one out of range", metadata.Warnings)
+			}
+		})
+	}
+}
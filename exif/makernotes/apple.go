@@ -1,38 +1,167 @@
 package makernotes
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
-	"log/slog"
 
 	"github.com/ZanyLeonic/exif-reader/exif/helpers"
 )
 
+// Apple MakerNote Tags. Tags 0x000d, 0x000e, 0x0016, 0x0018, 0x001b, 0x001c, 0x0022, 0x0028 to
+// 0x002a and 0x0031 to 0x0037 and 0x0039 to 0x003c are deliberately left undecoded: ExifTool lists
+// them without a name or meaning, so they are kept by number in UndecodedTags rather than named from
+// guesses at sample values. The lens is not among them; it is named by the EXIF LensModel tag.
+const (
+	AppleMakerNoteVersion           helpers.Tag = 0x0001
+	AppleRunTimeTag                 helpers.Tag = 0x0003
+	AppleAEStable                   helpers.Tag = 0x0004
+	AppleAETarget                   helpers.Tag = 0x0005
+	AppleAEAverage                  helpers.Tag = 0x0006
+	AppleAFStable                   helpers.Tag = 0x0007
+	AppleAccelerationVector         helpers.Tag = 0x0008
+	AppleHDRImageType               helpers.Tag = 0x000a
+	AppleBurstUUID                  helpers.Tag = 0x000b
+	AppleFocusDistanceRange         helpers.Tag = 0x000c
+	AppleOISMode                    helpers.Tag = 0x000f
+	AppleContentIdentifier          helpers.Tag = 0x0011
+	AppleImageCaptureType           helpers.Tag = 0x0014
+	AppleImageUniqueID              helpers.Tag = 0x0015
+	AppleLivePhotoVideoIndex        helpers.Tag = 0x0017
+	AppleImageProcessingFlags       helpers.Tag = 0x0019
+	AppleQualityHint                helpers.Tag = 0x001a
+	AppleLuminanceNoiseAmplitude    helpers.Tag = 0x001d
+	ApplePhotosAppFeatureFlags      helpers.Tag = 0x001f
+	AppleImageCaptureRequestID      helpers.Tag = 0x0020
+	AppleHDRHeadroom                helpers.Tag = 0x0021
+	AppleAFPerformance              helpers.Tag = 0x0023
+	AppleSceneFlags                 helpers.Tag = 0x0025
+	AppleSignalToNoiseRatioType     helpers.Tag = 0x0026
+	AppleSignalToNoiseRatio         helpers.Tag = 0x0027
+	ApplePhotoIdentifier            helpers.Tag = 0x002b
+	AppleColorTemperature           helpers.Tag = 0x002d
+	AppleCameraType                 helpers.Tag = 0x002e
+	AppleFocusPosition              helpers.Tag = 0x002f
+	AppleHDRGain                    helpers.Tag = 0x0030
+	AppleAFMeasuredDepth            helpers.Tag = 0x0038
+	AppleAFConfidence               helpers.Tag = 0x003d
+	AppleColorCorrectionMatrix      helpers.Tag = 0x003e
+	AppleGreenGhostMitigationStatus helpers.Tag = 0x003f
+	AppleSemanticStyle              helpers.Tag = 0x0040
+	AppleSemanticStyleRenderingVer  helpers.Tag = 0x0041
+	AppleSemanticStylePreset        helpers.Tag = 0x0042
+)
+
+var appleHDRImageType = map[int64]string{3: "HDR Image", 4: "Original Image"}
+
+var appleImageCaptureType = map[int64]string{
+	1: "ProRAW", 2: "Portrait", 10: "Photo", 11: "Manual Focus", 12: "Scene",
+}
+
+// appleCameraType holds the values ExifTool names. Other values, such as those of the telephoto and
+// ultra-wide cameras, have no documented meaning and are reported as "Unknown (n)" rather than guessed.
+var appleCameraType = map[int64]string{0: "Back Wide Angle", 1: "Back Normal", 6: "Front"}
+
+// AppleMakerNote The values decoded from an Apple MakerNote
+type AppleMakerNote struct {
+	MakerNoteVersion int32         `json:"makerNoteVersion"`
+	RunTime          *AppleRunTime `json:"runTime"`
+	// UptimeAtCapture is the time in seconds since the device booted, so photos taken without a
+	// restart in between can be placed on one timeline
+	UptimeAtCapture     float64   `json:"uptimeAtCapture"`
+	AEStable            bool      `json:"aeStable"`
+	AETarget            uint32    `json:"aeTarget"`
+	AEAverage           uint32    `json:"aeAverage"`
+	AFStable            bool      `json:"afStable"`
+	AccelerationVector  []float64 `json:"accelerationVector"`
+	HDRImageType        string    `json:"hdrImageType"`
+	BurstUUID           string    `json:"burstUUID"`
+	FocusDistanceRange  string    `json:"focusDistanceRange"`
+	OISMode             int32     `json:"oisMode"`
+	ContentIdentifier   string    `json:"contentIdentifier"`
+	ImageCaptureType    string    `json:"imageCaptureType"`
+	ImageUniqueID       string    `json:"imageUniqueID"`
+	LivePhotoVideoIndex int64     `json:"livePhotoVideoIndex"`
+	// LivePhotoVideoTime is LivePhotoVideoIndex in seconds
+	LivePhotoVideoTime         float64   `json:"livePhotoVideoTime"`
+	ImageProcessingFlags       int32     `json:"imageProcessingFlags"`
+	QualityHint                string    `json:"qualityHint"`
+	LuminanceNoiseAmplitude    float64   `json:"luminanceNoiseAmplitude"`
+	PhotosAppFeatureFlags      int32     `json:"photosAppFeatureFlags"`
+	ImageCaptureRequestID      string    `json:"imageCaptureRequestID"`
+	HDRHeadroom                float64   `json:"hdrHeadroom"`
+	AFPerformance              string    `json:"afPerformance"`
+	SceneFlags                 int32     `json:"sceneFlags"`
+	SignalToNoiseRatio         float64   `json:"signalToNoiseRatio"`
+	PhotoIdentifier            string    `json:"photoIdentifier"`
+	ColorTemperature           int32     `json:"colorTemperature"`
+	CameraType                 string    `json:"cameraType"`
+	FocusPosition              int32     `json:"focusPosition"`
+	HDRGain                    float64   `json:"hdrGain"`
+	AFMeasuredDepth            int32     `json:"afMeasuredDepth"`
+	AFConfidence               int32     `json:"afConfidence"`
+	SignalToNoiseRatioType     int32     `json:"signalToNoiseRatioType"`
+	ColorCorrectionMatrix      []float64 `json:"colorCorrectionMatrix"`
+	GreenGhostMitigationStatus int32     `json:"greenGhostMitigationStatus"`
+	// SemanticStyle is the Photographic Style dictionary as stored, with its keys numbered rather
+	// than named
+	SemanticStyle             map[string]interface{} `json:"semanticStyle"`
+	SemanticStyleRenderingVer int32                  `json:"semanticStyleRenderingVer"`
+	SemanticStylePreset       int32                  `json:"semanticStylePreset"`
+	// UndecodedTags holds the values of the tags without a known meaning, keyed by tag number such as
+	// "0x0009", with binary plists decoded
+	UndecodedTags map[string]interface{} `json:"undecodedTags"`
+}
+
+// AppleRunTime The device uptime when the photo was taken, as a CMTime: Value ticks of a clock
+// running Timescale times a second
+type AppleRunTime struct {
+	Flags     int64 `json:"flags"`
+	Value     int64 `json:"value"`
+	Epoch     int64 `json:"epoch"`
+	Timescale int64 `json:"timescale"`
+}
+
+// appleRunTimeValid is the CMTime flag set on times that hold a value
+const appleRunTimeValid = 1
+
+func (a *AppleMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(a)
+}
+
 type AppleParser struct{}
 
 func (p *AppleParser) Manufacturer() string {
 	return "Apple"
 }
 
-func (p *AppleParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry) (*map[string]interface{}, error) {
-	raw := e.GetByteArray(entry, e.TiffStart+int(entry.ValueOffset))
+func (p *AppleParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	raw := value.Bytes()
+	mnStart := value.Offset
+
+	if !bytes.HasPrefix(raw, []byte("Apple iOS\x00\x00\x01")) {
+		return nil, ErrNotMatched
+	}
 
 	// Minimum size check: 12-byte prefix + 2 endian + 2 magic + 4 offset + 2 count = 22 bytes
 	if len(raw) < 22 {
-		slog.Warn("Apple MakerNote data too short", "length", len(raw), "minimum", 22)
 		return nil, fmt.Errorf("apple makernote too short length: %d, minimum: 22", len(raw))
 	}
 
-	if string(raw[0:12]) != "Apple iOS\x00\x00\x01" {
-		slog.Warn("Apple MakerNote prefix mismatch", "prefix", fmt.Sprintf("%q", raw[0:12]))
-		return nil, fmt.Errorf("incorrect prefix, expected Apple iOS, got: %s", fmt.Sprintf("%q", raw[0:12]))
-	}
-
-	// Apple MakerNote uses TIFF structure starting at byte 12
-	// Parse the TIFF header using the standard function
-	mnEndian, err := helpers.DetermineEndianess(raw, 2)
-	if err != nil {
-		slog.Warn("Error parsing Apple MakerNote TIFF header", "err", err)
-		return nil, err
+	// The byte order mark follows the 12 byte signature
+	var mnEndian binary.ByteOrder
+	switch string(raw[12:14]) {
+	case "II":
+		mnEndian = binary.LittleEndian
+	case "MM":
+		mnEndian = binary.BigEndian
+	default:
+		return nil, errors.New("unsupported byte order")
 	}
 
 	// Unlike standard TIFF, there's no magic number or IFD offset pointer.
@@ -42,13 +171,10 @@ func (p *AppleParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry) (
 	mnTiffStart := 0 // Offsets are relative to byte 0 of MakerNote
 	mnFirstIfd := 14 // IFD starts at byte 14
 
-	slog.Debug("Apple MakerNote IFD location",
+	e.Context.Log().Debug("Apple MakerNote IFD location",
 		"ifdPosition", mnFirstIfd)
 
 	if mnFirstIfd+2 > len(raw) {
-		slog.Warn("IFD position out of bounds",
-			"ifdPos", mnFirstIfd,
-			"dataLen", len(raw))
 		return nil, fmt.Errorf("IFD position out of bounds, pos: %d, dataLength: %d", mnFirstIfd, len(raw))
 	}
 
@@ -58,154 +184,221 @@ func (p *AppleParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry) (
 	entriesStart := mnFirstIfd + 2
 	totalEntriesSize := int(entryCount) * 12
 	if entriesStart+totalEntriesSize > len(raw) {
-		slog.Warn("Not enough data for all MakerNote entries",
+		e.Context.Warn(helpers.DiagTruncated, "MakerNotes", mnStart+mnFirstIfd, "not enough data for all Apple MakerNote entries",
 			"entryCount", entryCount,
 			"entriesStart", entriesStart,
 			"requiredBytes", totalEntriesSize,
 			"availableBytes", len(raw)-entriesStart)
 		// Adjust entry count to what we can actually read
 		entryCount = uint16((len(raw) - entriesStart) / 12)
-		slog.Debug("Adjusted entry count to fit available data", "newCount", entryCount)
+		e.Context.Log().Debug("Adjusted entry count to fit available data", "newCount", entryCount)
 	}
 
-	slog.Debug("Apple MakerNote IFD parsed",
+	e.Context.Log().Debug("Apple MakerNote IFD parsed",
 		"entryCount", entryCount,
 		"entriesStart", entriesStart)
 
-	// Create helper for MakerNote parsing
+	if err := e.Context.EnterIFD("MakerNotes", mnStart+mnFirstIfd, 2+int(entryCount)*12); err != nil {
+		return nil, err
+	}
+
+	// Create helper for MakerNote parsing. It reads the parent data so that value offsets stay
+	// file offsets, with TiffStart at the start of the MakerNote.
 	mnHelper := helpers.ValueExtractor{
-		Data:      raw,
-		TiffStart: mnTiffStart,
+		Source:    e.Source,
+		TiffStart: mnStart + mnTiffStart,
 		Endian:    mnEndian,
+		Context:   e.Context,
 	}
 
-	parsed := make(map[string]interface{})
+	var parsed AppleMakerNote
+	haveVideoIndex := false
 
-	// Parse all entries
-	for j := 0; j < int(entryCount); j++ {
-		entryOffset := entriesStart + (j * 12)
-
-		entry := helpers.ParseIFDEntry(raw, entryOffset, mnEndian)
-
-		slog.Debug("Apple MakerNote entry",
-			"index", j,
-			"tag", fmt.Sprintf("0x%04x", entry.Tag),
-			"type", entry.DataType,
-			"count", entry.Count,
-			"valueOffset", entry.ValueOffset)
+	entries := make([]helpers.IFDEntry, 0, entryCount)
+	for j := range int(entryCount) {
+		entry, err := helpers.ParseIFDEntry(e.Source, mnStart+entriesStart+j*12, mnEndian)
+		if err != nil {
+			break
+		}
+		entries = append(entries, entry)
+	}
 
-		// Parse known tags
+	mnHelper.WalkEntries("MakerNotes", entries, func(entry helpers.IFDEntry, value helpers.TagValue) {
 		switch entry.Tag {
-		case 0x0001:
-			parsed["MakerNoteVersion"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x0003:
-			// RunTime - todo: parse plist
-		case 0x0004:
-			parsed["AEStable"] = mnHelper.GetUint32(entryOffset) == 1
-		case 0x0005:
-			parsed["AETarget"] = mnHelper.GetUint32(entryOffset)
-		case 0x0006:
-			parsed["AEAverage"] = mnHelper.GetUint32(entryOffset)
-		case 0x0007:
-			parsed["AFStable"] = mnHelper.GetUint32(entryOffset) == 1
-		case 0x0008:
-			x := mnHelper.GetRational(entry, 0, true)
-			y := mnHelper.GetRational(entry, 8, true)
-			z := mnHelper.GetRational(entry, 16, true)
-			parsed["AccelerationVector"] = []float64{x, y, z}
-		case 0x000a:
-			switch mnHelper.GetUint32(entryOffset) {
-			case 3:
-				parsed["HDRImageType"] = "HDR Image"
-			case 4:
-				parsed["HDRImageType"] = "Original Image"
-			default:
-				parsed["HDRImageType"] = "Unknown"
-			}
-		case 0x000b:
-			parsed["BurstUUID"] = mnHelper.GetString(entry, entryOffset)
-		case 0x000c:
-			p1 := mnHelper.GetRational(entry, 0, true)
-			p2 := mnHelper.GetRational(entry, 8, true)
-			parsed["FocusDistanceRange"] = fmt.Sprintf("%.2f - %.2f m", p1, p2)
-		case 0x000f:
-			parsed["OISMode"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x0011:
-			parsed["ContentIdentifier"] = mnHelper.GetString(entry, entryOffset)
-		case 0x0014:
-			switch int32(mnHelper.GetUint32(entryOffset)) {
-			case 1:
-				parsed["ImageCaptureType"] = "ProRAW"
-			case 2:
-				parsed["ImageCaptureType"] = "Portrait"
-			case 10:
-				parsed["ImageCaptureType"] = "Photo"
-			case 11:
-				parsed["ImageCaptureType"] = "Manual Focus"
-			case 12:
-				parsed["ImageCaptureType"] = "Scene"
-			default:
-				parsed["ImageCaptureType"] = "Unknown Value"
+		case AppleMakerNoteVersion:
+			parsed.MakerNoteVersion = int32(value.Int(0))
+		case AppleRunTimeTag:
+			runTime, err := decodeAppleRunTime(value.Bytes())
+			if err != nil {
+				mnHelper.Context.WarnTag(helpers.DiagDecodeFailed, "MakerNotes", entry, "cannot decode RunTime: "+err.Error())
+				return
 			}
-		case 0x0015:
-			parsed["ImageUniqueID"] = mnHelper.GetString(entry, entryOffset)
-		case 0x0017:
-			// todo - implement when runtime info is gathered
-			continue
-		case 0x0019:
-			parsed["ImageProcessingFlags"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x001a:
-			parsed["QualityHint"] = mnHelper.GetString(entry, entryOffset)
-		case 0x001d:
-			parsed["LuminanceNoiseAmplitude"] = mnHelper.GetRational(entry, 0, true)
-		case 0x001f:
-			parsed["PhotosAppFeatureFlags"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x0020:
-			parsed["ImageCaptureRequestID"] = mnHelper.GetString(entry, entryOffset)
-		case 0x0021:
-			parsed["HDRHeadroom"] = mnHelper.GetRational(entry, 0, true)
-		case 0x0023:
-			values := mnHelper.GetUint32Array(entry, 2)
-			if len(values) != 2 {
-				continue
+			parsed.RunTime = runTime
+		case AppleAEStable:
+			parsed.AEStable = uint32(value.Int(0)) == 1
+		case AppleAETarget:
+			parsed.AETarget = uint32(value.Int(0))
+		case AppleAEAverage:
+			parsed.AEAverage = uint32(value.Int(0))
+		case AppleAFStable:
+			parsed.AFStable = uint32(value.Int(0)) == 1
+		case AppleAccelerationVector:
+			x := value.Float(0)
+			y := value.Float(1)
+			z := value.Float(2)
+			parsed.AccelerationVector = []float64{x, y, z}
+		case AppleHDRImageType:
+			parsed.HDRImageType = lookup(appleHDRImageType, value.Int(0))
+		case AppleBurstUUID:
+			parsed.BurstUUID = value.String()
+		case AppleFocusDistanceRange:
+			p1 := value.Float(0)
+			p2 := value.Float(1)
+			parsed.FocusDistanceRange = fmt.Sprintf("%.2f - %.2f m", p1, p2)
+		case AppleOISMode:
+			parsed.OISMode = int32(value.Int(0))
+		case AppleContentIdentifier:
+			parsed.ContentIdentifier = value.String()
+		case AppleImageCaptureType:
+			parsed.ImageCaptureType = lookup(appleImageCaptureType, value.Int(0))
+		case AppleImageUniqueID:
+			parsed.ImageUniqueID = value.String()
+		case AppleLivePhotoVideoIndex:
+			parsed.LivePhotoVideoIndex = value.Int(0)
+			haveVideoIndex = true
+		case AppleImageProcessingFlags:
+			parsed.ImageProcessingFlags = int32(value.Int(0))
+		case AppleQualityHint:
+			parsed.QualityHint = value.String()
+		case AppleLuminanceNoiseAmplitude:
+			parsed.LuminanceNoiseAmplitude = value.Float(0)
+		case ApplePhotosAppFeatureFlags:
+			parsed.PhotosAppFeatureFlags = int32(value.Int(0))
+		case AppleImageCaptureRequestID:
+			parsed.ImageCaptureRequestID = value.String()
+		case AppleHDRHeadroom:
+			parsed.HDRHeadroom = value.Float(0)
+		case AppleAFPerformance:
+			if value.Len() != 2 {
+				return
 			}
 
-			focusDistance := int32(values[0])
-			packedValue := int32(values[1])
+			focusDistance := int32(value.Int(0))
+			packedValue := int32(value.Int(1))
 
 			highBits := (packedValue >> 28) & 0xf
 			lowBits := packedValue & 0xfffffff
 
-			parsed["AFPerformance"] = fmt.Sprintf("%d %d %d", focusDistance, highBits, lowBits)
-		case 0x0025:
-			parsed["SceneFlags"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x0027:
-			parsed["SignalToNoiseRatio"] = mnHelper.GetRational(entry, 0, true)
-		case 0x002b:
-			parsed["PhotoIdentifier"] = mnHelper.GetString(entry, entryOffset)
-		case 0x002d:
-			parsed["ColorTemperature"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x002e:
-			switch int32(mnHelper.GetUint32(entryOffset)) {
-			case 0:
-				parsed["CameraType"] = "Back Wide Angle"
-			case 1:
-				parsed["CameraType"] = "Back Normal"
-			case 6:
-				parsed["CameraType"] = "Front"
-			default:
-				parsed["CameraType"] = "Unknown"
+			parsed.AFPerformance = fmt.Sprintf("%d %d %d", focusDistance, highBits, lowBits)
+		case AppleSceneFlags:
+			parsed.SceneFlags = int32(value.Int(0))
+		case AppleSignalToNoiseRatio:
+			parsed.SignalToNoiseRatio = value.Float(0)
+		case ApplePhotoIdentifier:
+			parsed.PhotoIdentifier = value.String()
+		case AppleColorTemperature:
+			parsed.ColorTemperature = int32(value.Int(0))
+		case AppleCameraType:
+			parsed.CameraType = lookup(appleCameraType, value.Int(0))
+		case AppleFocusPosition:
+			parsed.FocusPosition = int32(value.Int(0))
+		case AppleHDRGain:
+			parsed.HDRGain = value.Float(0)
+		case AppleAFMeasuredDepth:
+			parsed.AFMeasuredDepth = int32(value.Int(0))
+		case AppleAFConfidence:
+			parsed.AFConfidence = int32(value.Int(0))
+		case AppleSignalToNoiseRatioType:
+			parsed.SignalToNoiseRatioType = int32(value.Int(0))
+		case AppleColorCorrectionMatrix:
+			parsed.ColorCorrectionMatrix = value.Floats()
+		case AppleGreenGhostMitigationStatus:
+			parsed.GreenGhostMitigationStatus = int32(value.Int(0))
+		case AppleSemanticStyle:
+			plist, err := helpers.DecodeBinaryPlist(value.Bytes())
+			style, ok := plist.(map[string]interface{})
+			if err != nil || !ok {
+				mnHelper.Context.WarnTag(helpers.DiagDecodeFailed, "MakerNotes", entry, "cannot decode SemanticStyle")
+				return
+			}
+			parsed.SemanticStyle = style
+		case AppleSemanticStyleRenderingVer:
+			parsed.SemanticStyleRenderingVer = int32(value.Int(0))
+		case AppleSemanticStylePreset:
+			parsed.SemanticStylePreset = int32(value.Int(0))
+		default:
+			if parsed.UndecodedTags == nil {
+				parsed.UndecodedTags = make(map[string]interface{})
+			}
+			parsed.UndecodedTags[fmt.Sprintf("0x%04x", entry.Tag)] = appleRawValue(value)
+		}
+	})
+
+	// Both the uptime and the Live Photo video index count ticks of the RunTime clock
+	if runTime := parsed.RunTime; runTime != nil {
+		if runTime.Flags&appleRunTimeValid == 0 || runTime.Timescale <= 0 {
+			e.Context.Warn(helpers.DiagInvalidValue, "MakerNotes", mnStart, "RunTime is not a valid time",
+				"flags", runTime.Flags, "timescale", runTime.Timescale)
+		} else {
+			parsed.UptimeAtCapture = float64(runTime.Value) / float64(runTime.Timescale)
+			if haveVideoIndex {
+				parsed.LivePhotoVideoTime = float64(parsed.LivePhotoVideoIndex) / float64(runTime.Timescale)
 			}
-		case 0x002f:
-			parsed["FocusPosition"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x0030:
-			parsed["HDRGain"] = mnHelper.GetRational(entry, 0, true)
-		case 0x0038:
-			parsed["AFMeasuredDepth"] = int32(mnHelper.GetUint32(entryOffset))
-		case 0x003d:
-			parsed["AFConfidence"] = int32(mnHelper.GetUint32(entryOffset))
 		}
 	}
 
 	return &parsed, nil
 }
+
+// decodeAppleRunTime decodes the binary plist of the RunTime tag, a dictionary of the CMTime fields
+func decodeAppleRunTime(raw []byte) (*AppleRunTime, error) {
+	plist, err := helpers.DecodeBinaryPlist(raw)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := plist.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("RunTime is %T, not a dictionary", plist)
+	}
+
+	var runTime AppleRunTime
+	for _, field := range []struct {
+		key string
+		dst *int64
+	}{
+		{"flags", &runTime.Flags},
+		{"value", &runTime.Value},
+		{"epoch", &runTime.Epoch},
+		{"timescale", &runTime.Timescale},
+	} {
+		value, ok := dict[field.key].(int64)
+		if !ok {
+			return nil, fmt.Errorf("RunTime %s is %T, not an integer", field.key, dict[field.key])
+		}
+		*field.dst = value
+	}
+	return &runTime, nil
+}
+
+// appleRawValue returns the value of an undecoded tag: text for strings, the decoded plist for
+// binary plists, a single number for counts of one and otherwise the decoded values as stored
+func appleRawValue(value helpers.TagValue) interface{} {
+	switch value.Type {
+	case helpers.TypeASCII:
+		return value.String()
+	case helpers.TypeUndefined:
+		if plist, err := helpers.DecodeBinaryPlist(value.Bytes()); err == nil {
+			return plist
+		}
+		return value.Bytes()
+	case helpers.TypeRational, helpers.TypeSRational, helpers.TypeFloat, helpers.TypeDouble:
+		if value.Len() == 1 {
+			return value.Float(0)
+		}
+		return value.Floats()
+	}
+	if value.Len() == 1 {
+		return value.Int(0)
+	}
+	return value.Value
+}
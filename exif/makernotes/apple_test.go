@@ -0,0 +1,174 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// runTimePlist encodes the RunTime dictionary as a binary plist
+func runTimePlist(flags, value, epoch, timescale int64) []byte {
+	return intPlist([]string{"flags", "value", "epoch", "timescale"}, []int64{flags, value, epoch, timescale})
+}
+
+// intPlist encodes a dictionary of integers as a binary plist, with the dictionary object followed
+// by its keys and then its values
+func intPlist(keys []string, values []int64) []byte {
+	out := []byte("bplist00")
+	offsets := []int{len(out)}
+	out = append(out, 0xd0|byte(len(keys)))
+	for i := range 2 * len(keys) {
+		out = append(out, byte(1+i))
+	}
+	for _, key := range keys {
+		offsets = append(offsets, len(out))
+		out = append(append(out, 0x50|byte(len(key))), key...)
+	}
+	for _, v := range values {
+		offsets = append(offsets, len(out))
+		out = binary.BigEndian.AppendUint64(append(out, 0x13), uint64(v))
+	}
+
+	table := len(out)
+	for _, offset := range offsets {
+		out = append(out, byte(offset))
+	}
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(offsets)))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(table))
+	return append(out, trailer...)
+}
+
+// appleRunTimeNote builds an Apple MakerNote holding a RunTime and a Live Photo video index. Offsets
+// count from the start of the MakerNote, and the IFD starts at byte 14.
+func appleRunTimeNote(runTime []byte) []byte {
+	return append([]byte("Apple iOS\x00\x00\x01MM"), makerNoteIFD(binary.BigEndian, 14, []noteEntry{
+		{0x0003, 7, uint32(len(runTime)), runTime},
+		{0x0017, 4, 1, binary.BigEndian.AppendUint32(nil, 2_500_000_000)},
+	})...)
+}
+
+func TestAppleParser(t *testing.T) {
+	note := appleMakerNote([][3]uint32{{0x0001, 9, 14}, {0x0004, 9, 1}, {0x000a, 9, 3}, {0x0014, 9, 10}, {0x002e, 9, 6}})
+
+	e, entry := makerNoteExtractor(note)
+	detection, parsed, err := DetectAndParse(e, entry, "Apple")
+	if err != nil || detection.Manufacturer != "Apple" {
+		t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+	}
+
+	apple, ok := parsed.(*AppleMakerNote)
+	if !ok {
+		t.Fatalf("parsed is %T, want *AppleMakerNote", parsed)
+	}
+	want := AppleMakerNote{
+		MakerNoteVersion: 14,
+		AEStable:         true,
+		HDRImageType:     "HDR Image",
+		ImageCaptureType: "Photo",
+		CameraType:       "Front",
+	}
+	if !reflect.DeepEqual(*apple, want) {
+		t.Errorf("parsed = %+v, want %+v", *apple, want)
+	}
+
+	// The generic view uses the JSON names
+	fields := parsed.Fields()
+	if fields["hdrImageType"] != "HDR Image" || fields["makerNoteVersion"] != int32(14) {
+		t.Errorf("Fields() = %v", fields)
+	}
+	out, err := json.Marshal(parsed)
+	if err != nil || !strings.Contains(string(out), `"imageCaptureType":"Photo"`) {
+		t.Errorf("json.Marshal() = %s, %v", out, err)
+	}
+}
+
+func TestAppleRunTime(t *testing.T) {
+	e, entry := makerNoteExtractor(appleRunTimeNote(runTimePlist(1, 3_723_500_000_000, 0, 1_000_000_000)))
+	e.Context = &helpers.ParseContext{}
+	_, parsed, err := DetectAndParse(e, entry, "Apple")
+	if err != nil {
+		t.Fatalf("DetectAndParse() error = %v", err)
+	}
+	if len(e.Context.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+	}
+
+	apple := parsed.(*AppleMakerNote)
+	want := AppleRunTime{Flags: 1, Value: 3_723_500_000_000, Epoch: 0, Timescale: 1_000_000_000}
+	if apple.RunTime == nil || *apple.RunTime != want {
+		t.Errorf("RunTime = %+v, want %+v", apple.RunTime, want)
+	}
+	if apple.UptimeAtCapture != 3723.5 {
+		t.Errorf("UptimeAtCapture = %v, want 3723.5", apple.UptimeAtCapture)
+	}
+	if apple.LivePhotoVideoIndex != 2_500_000_000 || apple.LivePhotoVideoTime != 2.5 {
+		t.Errorf("LivePhotoVideoIndex = %d, LivePhotoVideoTime = %v", apple.LivePhotoVideoIndex, apple.LivePhotoVideoTime)
+	}
+}
+
+func TestAppleRunTimeInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		runTime []byte
+		code    string
+	}{
+		// Without the valid flag the value is not a time
+		{"invalid flag", runTimePlist(0, 1000, 0, 1000), helpers.DiagInvalidValue},
+		{"corrupt plist", []byte("bplist00 truncated"), helpers.DiagDecodeFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, entry := makerNoteExtractor(appleRunTimeNote(tt.runTime))
+			e.Context = &helpers.ParseContext{}
+			_, parsed, err := DetectAndParse(e, entry, "Apple")
+			if err != nil {
+				t.Fatalf("DetectAndParse() error = %v", err)
+			}
+			if apple := parsed.(*AppleMakerNote); apple.UptimeAtCapture != 0 || apple.LivePhotoVideoTime != 0 {
+				t.Errorf("UptimeAtCapture = %v, LivePhotoVideoTime = %v, want neither", apple.UptimeAtCapture, apple.LivePhotoVideoTime)
+			}
+			if len(e.Context.Diagnostics) != 1 || e.Context.Diagnostics[0].Code != tt.code {
+				t.Errorf("diagnostics = %v, want one %s", e.Context.Diagnostics, tt.code)
+			}
+		})
+	}
+}
+
+func TestAppleUndecodedTags(t *testing.T) {
+	style := intPlist([]string{"_0", "_1"}, []int64{-50, 25})
+	note := append([]byte("Apple iOS\x00\x00\x01MM"), makerNoteIFD(binary.BigEndian, 14, []noteEntry{
+		{0x0009, 9, 1, []byte{0, 0, 0, 5}},
+		{0x0016, 2, 6, []byte("AXZ6p\x00")},
+		{0x002e, 9, 1, []byte{0, 0, 0, 2}},
+		{0x0031, 9, 1, []byte{0, 0, 0, 1}},
+		{0x0040, 7, uint32(len(style)), style},
+		{0x0042, 9, 1, []byte{0, 0, 0, 3}},
+	})...)
+
+	e, entry := makerNoteExtractor(note)
+	_, parsed, err := DetectAndParse(e, entry, "Apple")
+	if err != nil {
+		t.Fatalf("DetectAndParse() error = %v", err)
+	}
+	apple := parsed.(*AppleMakerNote)
+
+	if want := map[string]interface{}{"0x0009": int64(5), "0x0016": "AXZ6p", "0x0031": int64(1)}; !reflect.DeepEqual(apple.UndecodedTags, want) {
+		t.Errorf("UndecodedTags = %v, want %v", apple.UndecodedTags, want)
+	}
+	// Camera types without a known name keep their number
+	if apple.CameraType != "Unknown (2)" {
+		t.Errorf("CameraType = %q", apple.CameraType)
+	}
+	if want := map[string]interface{}{"_0": int64(-50), "_1": int64(25)}; !reflect.DeepEqual(apple.SemanticStyle, want) {
+		t.Errorf("SemanticStyle = %v, want %v", apple.SemanticStyle, want)
+	}
+	if apple.SemanticStylePreset != 3 {
+		t.Errorf("SemanticStylePreset = %d", apple.SemanticStylePreset)
+	}
+}
@@ -0,0 +1,359 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Canon MakerNote Tags
+const (
+	CanonCameraSettings       helpers.Tag = 0x0001
+	CanonShotInfo             helpers.Tag = 0x0004
+	CanonImageType            helpers.Tag = 0x0006
+	CanonFirmwareVersion      helpers.Tag = 0x0007
+	CanonFileNumber           helpers.Tag = 0x0008
+	CanonOwnerName            helpers.Tag = 0x0009
+	CanonSerialNumber         helpers.Tag = 0x000c
+	CanonModelID              helpers.Tag = 0x0010
+	CanonImageUniqueID        helpers.Tag = 0x0028
+	CanonLensModel            helpers.Tag = 0x0095
+	CanonInternalSerialNumber helpers.Tag = 0x0096
+)
+
+var canonQuality = map[int64]string{
+	1: "Economy", 2: "Normal", 3: "Fine", 4: "RAW", 5: "Superfine", 7: "CRAW",
+	130: "Light (RAW)", 131: "Standard (RAW)",
+}
+
+var canonFlashMode = map[int64]string{
+	0: "Off", 1: "Auto", 2: "On", 3: "Red-eye reduction", 4: "Slow-sync",
+	5: "Red-eye reduction (Auto)", 6: "Red-eye reduction (On)", 16: "External flash",
+}
+
+var canonContinuousDrive = map[int64]string{
+	0: "Single", 1: "Continuous", 2: "Movie", 3: "Continuous, Speed Priority", 4: "Continuous, Low",
+	5: "Continuous, High", 6: "Silent Single", 9: "Single, Silent", 10: "Continuous, Silent",
+}
+
+var canonFocusMode = map[int64]string{
+	0: "One-shot AF", 1: "AI Servo AF", 2: "AI Focus AF", 3: "Manual Focus", 4: "Single", 5: "Continuous",
+	6: "Manual Focus", 16: "Pan Focus", 256: "One-shot AF (Live View)", 257: "AI Servo AF (Live View)",
+	258: "AI Focus AF (Live View)", 512: "Movie Snap Focus", 519: "Movie Servo AF",
+}
+
+var canonRecordMode = map[int64]string{
+	1: "JPEG", 2: "CRW+THM", 3: "AVI+THM", 4: "TIF", 5: "TIF+JPEG", 6: "CR2", 7: "CR2+JPEG", 9: "MOV",
+	10: "MP4", 11: "CRM", 12: "CR3", 13: "CR3+JPEG", 14: "HIF", 15: "CR3+HIF",
+}
+
+var canonMeteringMode = map[int64]string{
+	0: "Default", 1: "Spot", 2: "Average", 3: "Evaluative", 4: "Partial", 5: "Center-weighted average",
+}
+
+var canonExposureMode = map[int64]string{
+	0: "Easy", 1: "Program AE", 2: "Shutter speed priority AE", 3: "Aperture-priority AE", 4: "Manual",
+	5: "Depth-of-field AE", 6: "M-Dep", 7: "Bulb", 8: "Flexible-priority AE",
+}
+
+var canonImageStabilization = map[int64]string{
+	0: "Off", 1: "On", 2: "Shoot Only", 3: "Panning", 4: "Dynamic",
+	256: "Off (2)", 257: "On (2)", 258: "Shoot Only (2)", 259: "Panning (2)", 260: "Dynamic (2)",
+}
+
+var canonWhiteBalance = map[int64]string{
+	0: "Auto", 1: "Daylight", 2: "Cloudy", 3: "Tungsten", 4: "Fluorescent", 5: "Flash", 6: "Custom",
+	7: "Black & White", 8: "Shade", 9: "Manual Temperature (Kelvin)", 14: "Daylight Fluorescent",
+	17: "Under Water", 20: "Custom 1", 21: "Custom 2",
+}
+
+var canonAutoRotate = map[int64]string{
+	-1: "n/a", 0: "None", 1: "Rotate 90 CW", 2: "Rotate 180", 3: "Rotate 270 CW",
+}
+
+// CanonMakerNote The values decoded from a Canon MakerNote, including its CameraSettings and ShotInfo
+// arrays
+type CanonMakerNote struct {
+	MacroMode string `json:"macroMode,omitempty"`
+	// SelfTimer is the delay in seconds
+	SelfTimer          float64 `json:"selfTimer,omitempty"`
+	Quality            string  `json:"quality,omitempty"`
+	FlashMode          string  `json:"flashMode,omitempty"`
+	ContinuousDrive    string  `json:"continuousDrive,omitempty"`
+	FocusMode          string  `json:"focusMode,omitempty"`
+	RecordMode         string  `json:"recordMode,omitempty"`
+	MeteringMode       string  `json:"meteringMode,omitempty"`
+	ExposureMode       string  `json:"exposureMode,omitempty"`
+	LensType           uint16  `json:"lensType,omitempty"`
+	MaxFocalLength     float64 `json:"maxFocalLength,omitempty"`
+	MinFocalLength     float64 `json:"minFocalLength,omitempty"`
+	MaxAperture        float64 `json:"maxAperture,omitempty"`
+	MinAperture        float64 `json:"minAperture,omitempty"`
+	ImageStabilization string  `json:"imageStabilization,omitempty"`
+
+	AutoISO              float64  `json:"autoISO,omitempty"`
+	BaseISO              float64  `json:"baseISO,omitempty"`
+	MeasuredEV           *float64 `json:"measuredEV,omitempty"`
+	TargetAperture       float64  `json:"targetAperture,omitempty"`
+	TargetExposureTime   float64  `json:"targetExposureTime,omitempty"`
+	ExposureCompensation *float64 `json:"exposureCompensation,omitempty"`
+	WhiteBalance         string   `json:"whiteBalance,omitempty"`
+	SequenceNumber       int16    `json:"sequenceNumber,omitempty"`
+	// CameraTemperature is in degrees Celsius
+	CameraTemperature *int `json:"cameraTemperature,omitempty"`
+	// FocusDistanceUpper and FocusDistanceLower are in metres, or "inf"
+	FocusDistanceUpper string  `json:"focusDistanceUpper,omitempty"`
+	FocusDistanceLower string  `json:"focusDistanceLower,omitempty"`
+	FNumber            float64 `json:"fNumber,omitempty"`
+	ExposureTime       float64 `json:"exposureTime,omitempty"`
+	AutoRotate         string  `json:"autoRotate,omitempty"`
+
+	ImageType            string `json:"imageType,omitempty"`
+	FirmwareVersion      string `json:"firmwareVersion,omitempty"`
+	FileNumber           string `json:"fileNumber,omitempty"`
+	OwnerName            string `json:"ownerName,omitempty"`
+	SerialNumber         string `json:"serialNumber,omitempty"`
+	ModelID              string `json:"modelID,omitempty"`
+	ImageUniqueID        string `json:"imageUniqueID,omitempty"`
+	LensModel            string `json:"lensModel,omitempty"`
+	InternalSerialNumber string `json:"internalSerialNumber,omitempty"`
+}
+
+func (c *CanonMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(c)
+}
+
+type CanonParser struct{}
+
+func (p *CanonParser) Manufacturer() string {
+	return "Canon"
+}
+
+// Parse decodes a Canon MakerNote, which is a bare IFD with no header. Unlike most vendors, its
+// value offsets are relative to the EXIF TIFF header rather than to the MakerNote.
+func (p *CanonParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	if !hasPrefixFold(cameraMake, "Canon") {
+		return nil, ErrNotMatched
+	}
+
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	raw := value.Bytes()
+	mnStart := value.Offset
+
+	// Canon ends the MakerNote with a TIFF byte order mark and its original offset from the TIFF
+	// header. An editor that moved the MakerNote without rewriting it leaves the values behind by
+	// the distance moved, which is itself worth reporting.
+	base := e.TiffStart
+	if len(raw) >= 8 {
+		footer := raw[len(raw)-8:]
+		var footerEndian binary.ByteOrder
+		switch string(footer[0:4]) {
+		case "II*\x00":
+			footerEndian = binary.LittleEndian
+		case "MM\x00*":
+			footerEndian = binary.BigEndian
+		}
+		if footerEndian != nil {
+			original := int(footerEndian.Uint32(footer[4:8]))
+			if moved := mnStart - e.TiffStart - original; moved != 0 {
+				e.Context.Warn(helpers.DiagRelocated, "MakerNotes", mnStart, "Canon MakerNote was moved from its original offset, adjusting value offsets",
+					"original", original, "moved", moved)
+				base += moved
+			}
+		}
+	}
+
+	mnHelper := helpers.ValueExtractor{
+		Source:    e.Source,
+		TiffStart: base,
+		Endian:    e.Endian,
+		Context:   e.Context,
+	}
+
+	parsed := &CanonMakerNote{}
+
+	_, err = mnHelper.WalkIFD("MakerNotes", mnStart, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case CanonCameraSettings:
+			decodeCanonCameraSettings(value, parsed)
+		case CanonShotInfo:
+			decodeCanonShotInfo(value, parsed)
+		case CanonImageType:
+			parsed.ImageType = value.String()
+		case CanonFirmwareVersion:
+			parsed.FirmwareVersion = value.String()
+		case CanonFileNumber:
+			// The camera's internal image counter, formatted as folder-file
+			fileNumber := uint32(value.Int(0))
+			parsed.FileNumber = fmt.Sprintf("%d-%04d", fileNumber/10000, fileNumber%10000)
+		case CanonOwnerName:
+			parsed.OwnerName = value.String()
+		case CanonSerialNumber:
+			parsed.SerialNumber = fmt.Sprintf("%010d", uint32(value.Int(0)))
+		case CanonModelID:
+			parsed.ModelID = fmt.Sprintf("0x%08x", uint32(value.Int(0)))
+		case CanonImageUniqueID:
+			parsed.ImageUniqueID = hex.EncodeToString(value.Bytes())
+		case CanonLensModel:
+			parsed.LensModel = value.String()
+		case CanonInternalSerialNumber:
+			parsed.InternalSerialNumber = strings.TrimRight(value.String(), "\xff")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// decodeCanonCameraSettings decodes the CameraSettings array of signed 16-bit values. The first
+// value is the array's size in bytes, so fields are numbered from 1.
+func decodeCanonCameraSettings(value helpers.TagValue, parsed *CanonMakerNote) {
+	get := func(i int) int16 { return int16(value.Int(i)) }
+	n := value.Len()
+
+	if n > 1 {
+		parsed.MacroMode = lookup(map[int64]string{1: "Macro", 2: "Normal"}, int64(get(1)))
+	}
+	if n > 2 {
+		parsed.SelfTimer = float64(get(2)) / 10
+	}
+	if n > 3 {
+		parsed.Quality = lookup(canonQuality, int64(get(3)))
+	}
+	if n > 4 {
+		parsed.FlashMode = lookup(canonFlashMode, int64(get(4)))
+	}
+	if n > 5 {
+		parsed.ContinuousDrive = lookup(canonContinuousDrive, int64(get(5)))
+	}
+	if n > 7 {
+		parsed.FocusMode = lookup(canonFocusMode, int64(get(7)))
+	}
+	if n > 9 {
+		parsed.RecordMode = lookup(canonRecordMode, int64(get(9)))
+	}
+	if n > 17 {
+		parsed.MeteringMode = lookup(canonMeteringMode, int64(get(17)))
+	}
+	if n > 20 {
+		parsed.ExposureMode = lookup(canonExposureMode, int64(get(20)))
+	}
+	if n > 22 {
+		parsed.LensType = uint16(get(22))
+	}
+	if n > 25 {
+		// Focal lengths are stored in FocalUnits per mm
+		units := float64(uint16(get(25)))
+		if units == 0 {
+			units = 1
+		}
+		parsed.MaxFocalLength = float64(uint16(get(23))) / units
+		parsed.MinFocalLength = float64(uint16(get(24))) / units
+	}
+	if n > 27 {
+		parsed.MaxAperture = canonAperture(get(26))
+		parsed.MinAperture = canonAperture(get(27))
+	}
+	if n > 34 {
+		parsed.ImageStabilization = lookup(canonImageStabilization, int64(get(34)))
+	}
+}
+
+// decodeCanonShotInfo decodes the ShotInfo array, which like CameraSettings is numbered from 1. Most
+// exposure values are in Canon's APEX-like units of 1/32 EV.
+func decodeCanonShotInfo(value helpers.TagValue, parsed *CanonMakerNote) {
+	get := func(i int) int16 { return int16(value.Int(i)) }
+	n := value.Len()
+
+	if n > 2 {
+		parsed.AutoISO = math.Round(math.Exp2(float64(get(1))/32) * 100)
+		parsed.BaseISO = math.Round(math.Exp2(float64(get(2))/32) * 100 / 32)
+	}
+	if n > 3 {
+		measuredEV := float64(get(3))/32 + 5
+		parsed.MeasuredEV = &measuredEV
+	}
+	if n > 5 {
+		if get(4) != 0 {
+			parsed.TargetAperture = canonAperture(get(4))
+		}
+		if get(5) != 0 {
+			parsed.TargetExposureTime = canonExposureTime(get(5))
+		}
+	}
+	if n > 6 {
+		exposureCompensation := canonEV(get(6))
+		parsed.ExposureCompensation = &exposureCompensation
+	}
+	if n > 7 {
+		parsed.WhiteBalance = lookup(canonWhiteBalance, int64(get(7)))
+	}
+	if n > 9 {
+		parsed.SequenceNumber = get(9)
+	}
+	// Zero means the camera does not record its temperature
+	if n > 12 && get(12) != 0 {
+		temperature := int(get(12)) - 128
+		parsed.CameraTemperature = &temperature
+	}
+	if n > 20 {
+		parsed.FocusDistanceUpper = canonFocusDistance(get(19))
+		parsed.FocusDistanceLower = canonFocusDistance(get(20))
+	}
+	if n > 22 {
+		if get(21) != 0 {
+			parsed.FNumber = canonAperture(get(21))
+		}
+		if get(22) != 0 {
+			parsed.ExposureTime = canonExposureTime(get(22))
+		}
+	}
+	if n > 27 {
+		parsed.AutoRotate = lookup(canonAutoRotate, int64(get(27)))
+	}
+}
+
+// canonEV converts Canon's 1/32 EV units, where fractions of 0x0c and 0x14 stand for 1/3 and 2/3
+// (ported from the exiftool project)
+func canonEV(raw int16) float64 {
+	val := int(raw)
+	sign := 1.0
+	if val < 0 {
+		val, sign = -val, -1
+	}
+
+	frac := float64(val & 0x1f)
+	switch val & 0x1f {
+	case 0x0c:
+		frac = 0x20 / 3.0
+	case 0x14:
+		frac = 0x40 / 3.0
+	}
+	return sign * (float64(val&^0x1f) + frac) / 0x20
+}
+
+func canonAperture(raw int16) float64 {
+	return math.Round(math.Exp2(canonEV(raw)/2)*10) / 10
+}
+
+func canonExposureTime(raw int16) float64 {
+	return math.Exp2(-canonEV(raw))
+}
+
+// canonFocusDistance converts centimetres to metres, with 65535 meaning infinity
+func canonFocusDistance(raw int16) string {
+	if uint16(raw) == 0xffff {
+		return "inf"
+	}
+	return strconv.FormatFloat(float64(uint16(raw))/100, 'f', -1, 64)
+}
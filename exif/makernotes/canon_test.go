@@ -0,0 +1,94 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestCanonParser(t *testing.T) {
+	// CameraSettings up to ExposureMode, then ShotInfo up to FNumber and ExposureTime
+	cameraSettings := shorts(42, 2, 0, 3, 0, 1, 0, 1, 0, 6, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 3)
+	shotInfo := shorts(46, 0, 160, 0, 0, 0, -12, 0, 0, 7, 0, 0, 150, 0, 0, 0, 0, 0, 0, 0, 0, 128, 96)
+	serial := binary.BigEndian.AppendUint32(nil, 123456789)
+
+	note := canonMakerNote([]noteEntry{
+		{0x0001, 3, uint32(len(cameraSettings) / 2), cameraSettings},
+		{0x0004, 3, uint32(len(shotInfo) / 2), shotInfo},
+		{0x0007, 2, 16, []byte("Firmware 1.1.0\x00\x00")},
+		{0x0009, 2, 12, []byte("Jane Doe\x00\x00\x00\x00")},
+		{0x000c, 4, 1, serial},
+		{0x0095, 2, 16, []byte("EF24-70mm f/2.8L")},
+	})
+
+	e, entry := makerNoteExtractor(note)
+	e.Context = &helpers.ParseContext{}
+	detection, parsed, err := DetectAndParse(e, entry, "Canon")
+	if err != nil || detection.Manufacturer != "Canon" {
+		t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+	}
+	if len(e.Context.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+	}
+
+	canon, ok := parsed.(*CanonMakerNote)
+	if !ok {
+		t.Fatalf("parsed is %T, want *CanonMakerNote", parsed)
+	}
+	want := CanonMakerNote{
+		MacroMode:            "Normal",
+		Quality:              "Fine",
+		FlashMode:            "Off",
+		ContinuousDrive:      "Continuous",
+		FocusMode:            "AI Servo AF",
+		RecordMode:           "CR2",
+		MeteringMode:         "Evaluative",
+		ExposureMode:         "Aperture-priority AE",
+		AutoISO:              100,
+		BaseISO:              100,
+		MeasuredEV:           ptr(5.0),
+		ExposureCompensation: ptr(-1.0 / 3),
+		WhiteBalance:         "Auto",
+		SequenceNumber:       7,
+		CameraTemperature:    ptr(22),
+		FocusDistanceUpper:   "0",
+		FocusDistanceLower:   "0",
+		FNumber:              4,
+		ExposureTime:         0.125,
+		FirmwareVersion:      "Firmware 1.1.0",
+		OwnerName:            "Jane Doe",
+		SerialNumber:         "0123456789",
+		LensModel:            "EF24-70mm f/2.8L",
+	}
+	if !reflect.DeepEqual(*canon, want) {
+		t.Errorf("parsed = %+v, want %+v", *canon, want)
+	}
+
+	// Other manufacturers' notes are left to their own parsers
+	if _, err := (&CanonParser{}).Parse(e, entry, "NIKON CORPORATION"); err != ErrNotMatched {
+		t.Errorf("non-Canon make: got %v, want ErrNotMatched", err)
+	}
+}
+
+func TestCanonParserRelocated(t *testing.T) {
+	// The footer records an original offset 4 bytes later than where the MakerNote now sits, so
+	// the values must be read 4 bytes earlier than their stored offsets say
+	note := makerNoteIFD(binary.BigEndian, 16, []noteEntry{{0x0095, 2, 8, []byte("EF50mm\x00\x00")}})
+	note = append(note, "MM\x00*"...)
+	note = binary.BigEndian.AppendUint32(note, 16)
+
+	e, entry := makerNoteExtractor(note)
+	e.Context = &helpers.ParseContext{}
+	_, parsed, err := DetectAndParse(e, entry, "Canon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.(*CanonMakerNote).LensModel; got != "EF50mm" {
+		t.Errorf("LensModel = %q, want EF50mm", got)
+	}
+	if len(e.Context.Diagnostics) != 1 || e.Context.Diagnostics[0].Code != helpers.DiagRelocated {
+		t.Errorf("want one relocated diagnostic, got %v", e.Context.Diagnostics)
+	}
+}
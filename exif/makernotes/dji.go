@@ -0,0 +1,90 @@
+package makernotes
+
+import (
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// DJI MakerNote Tags
+const (
+	DJIMake        helpers.Tag = 0x0001
+	DJISpeedX      helpers.Tag = 0x0003
+	DJISpeedY      helpers.Tag = 0x0004
+	DJISpeedZ      helpers.Tag = 0x0005
+	DJIPitch       helpers.Tag = 0x0006
+	DJIYaw         helpers.Tag = 0x0007
+	DJIRoll        helpers.Tag = 0x0008
+	DJICameraPitch helpers.Tag = 0x0009
+	DJICameraYaw   helpers.Tag = 0x000a
+	DJICameraRoll  helpers.Tag = 0x000b
+)
+
+// DJIMakerNote The values decoded from a DJI MakerNote. Pitch, Yaw and Roll are the aircraft's in
+// degrees, and the Camera values the gimbal's. Speeds are in metres per second.
+type DJIMakerNote struct {
+	Make        string  `json:"make,omitempty"`
+	SpeedX      float64 `json:"speedX"`
+	SpeedY      float64 `json:"speedY"`
+	SpeedZ      float64 `json:"speedZ"`
+	Pitch       float64 `json:"pitch"`
+	Yaw         float64 `json:"yaw"`
+	Roll        float64 `json:"roll"`
+	CameraPitch float64 `json:"cameraPitch"`
+	CameraYaw   float64 `json:"cameraYaw"`
+	CameraRoll  float64 `json:"cameraRoll"`
+}
+
+func (d *DJIMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(d)
+}
+
+type DJIParser struct{}
+
+func (p *DJIParser) Manufacturer() string {
+	return "DJI"
+}
+
+// Parse decodes a DJI MakerNote, a bare IFD recognised by the Make tag whose value offsets are
+// relative to the EXIF TIFF header
+func (p *DJIParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	if !hasPrefixFold(cameraMake, "DJI") {
+		return nil, ErrNotMatched
+	}
+
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	mnStart := value.Offset
+
+	parsed := &DJIMakerNote{}
+
+	_, err = e.WalkIFD("MakerNotes", mnStart, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case DJIMake:
+			parsed.Make = value.String()
+		case DJISpeedX:
+			parsed.SpeedX = value.Float(0)
+		case DJISpeedY:
+			parsed.SpeedY = value.Float(0)
+		case DJISpeedZ:
+			parsed.SpeedZ = value.Float(0)
+		case DJIPitch:
+			parsed.Pitch = value.Float(0)
+		case DJIYaw:
+			parsed.Yaw = value.Float(0)
+		case DJIRoll:
+			parsed.Roll = value.Float(0)
+		case DJICameraPitch:
+			parsed.CameraPitch = value.Float(0)
+		case DJICameraYaw:
+			parsed.CameraYaw = value.Float(0)
+		case DJICameraRoll:
+			parsed.CameraRoll = value.Float(0)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
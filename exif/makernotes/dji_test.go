@@ -0,0 +1,39 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestDJIParser(t *testing.T) {
+	note := makerNoteIFD(binary.BigEndian, 12, []noteEntry{
+		{0x0001, 2, 4, []byte("DJI\x00")},
+		{0x0003, 11, 1, []byte{0x40, 0x20, 0x00, 0x00}},
+		{0x0009, 11, 1, []byte{0xc2, 0x34, 0x00, 0x00}},
+	})
+
+	// Some firmware writes the make in lower case
+	for _, cameraMake := range []string{"DJI", "dji"} {
+		e, entry := makerNoteExtractor(note)
+		e.Context = &helpers.ParseContext{}
+		detection, parsed, err := DetectAndParse(e, entry, cameraMake)
+		if err != nil || detection.Manufacturer != "DJI" {
+			t.Fatalf("DetectAndParse(%q) = %+v, %v", cameraMake, detection, err)
+		}
+		if len(e.Context.Diagnostics) != 0 {
+			t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+		}
+
+		want := DJIMakerNote{Make: "DJI", SpeedX: 2.5, CameraPitch: -45}
+		if got, ok := parsed.(*DJIMakerNote); !ok || *got != want {
+			t.Errorf("parsed = %+v, want %+v", parsed, want)
+		}
+	}
+
+	e, entry := makerNoteExtractor(note)
+	if _, err := (&DJIParser{}).Parse(e, entry, "Canon"); err != ErrNotMatched {
+		t.Errorf("non-DJI make: got %v, want ErrNotMatched", err)
+	}
+}
@@ -0,0 +1,130 @@
+package makernotes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Fujifilm MakerNote Tags
+const (
+	FujifilmVersion               helpers.Tag = 0x0000
+	FujifilmInternalSerialNumber  helpers.Tag = 0x0010
+	FujifilmQuality               helpers.Tag = 0x1000
+	FujifilmSaturation            helpers.Tag = 0x1003
+	FujifilmFilmMode              helpers.Tag = 0x1401
+	FujifilmMinFocalLength        helpers.Tag = 0x1404
+	FujifilmMaxFocalLength        helpers.Tag = 0x1405
+	FujifilmMaxApertureAtMinFocal helpers.Tag = 0x1406
+	FujifilmMaxApertureAtMaxFocal helpers.Tag = 0x1407
+	FujifilmImageCount            helpers.Tag = 0x1438
+)
+
+var fujifilmFilmMode = map[int64]string{
+	0x000: "F0/Standard (Provia)", 0x100: "F1/Studio Portrait", 0x110: "F1a/Studio Portrait Enhanced Saturation",
+	0x120: "F1b/Studio Portrait Smooth Skin Tone (Astia)", 0x130: "F1c/Studio Portrait Increased Sharpness",
+	0x200: "F2/Fujichrome (Velvia)", 0x300: "F3/Studio Portrait Ex", 0x400: "F4/Velvia", 0x500: "Pro Neg. Std",
+	0x501: "Pro Neg. Hi", 0x600: "Classic Chrome", 0x700: "Eterna", 0x800: "Classic Negative",
+	0x900: "Bleach Bypass", 0xa00: "Nostalgic Neg", 0xb00: "Reala ACE",
+}
+
+var fujifilmSaturation = map[int64]string{
+	0x000: "0 (normal)", 0x080: "+1 (medium high)", 0x0c0: "+3 (very high)", 0x0e0: "+4 (highest)",
+	0x100: "+2 (high)", 0x180: "-1 (medium low)", 0x200: "Low", 0x300: "None (B&W)", 0x301: "B&W Red Filter",
+	0x302: "B&W Yellow Filter", 0x303: "B&W Green Filter", 0x310: "B&W Sepia", 0x400: "-2 (low)",
+	0x4c0: "-3 (very low)", 0x4e0: "-4 (lowest)", 0x500: "Acros", 0x501: "Acros Red Filter",
+	0x502: "Acros Yellow Filter", 0x503: "Acros Green Filter", 0x8000: "Film Simulation",
+}
+
+// FujifilmMakerNote The values decoded from a Fujifilm MakerNote
+type FujifilmMakerNote struct {
+	MakerNoteVersion     string `json:"makerNoteVersion,omitempty"`
+	InternalSerialNumber string `json:"internalSerialNumber,omitempty"`
+	Quality              string `json:"quality,omitempty"`
+	Saturation           string `json:"saturation,omitempty"`
+	// FilmMode is the film simulation, including the monochrome ones recorded as a saturation
+	FilmMode   string `json:"filmMode,omitempty"`
+	Lens       string `json:"lens,omitempty"`
+	ImageCount uint16 `json:"imageCount,omitempty"`
+}
+
+func (f *FujifilmMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(f)
+}
+
+type FujifilmParser struct{}
+
+func (p *FujifilmParser) Manufacturer() string {
+	return "Fujifilm"
+}
+
+// Parse decodes a Fujifilm MakerNote: "FUJIFILM" and the offset of the IFD, which like every value
+// offset is relative to the start of the MakerNote. It is always little-endian. The MakerNote has no
+// firmware version, which Fujifilm records in the EXIF Software tag instead.
+func (p *FujifilmParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	raw := value.Bytes()
+	mnStart := value.Offset
+
+	if !bytes.HasPrefix(raw, []byte("FUJIFILM")) {
+		return nil, ErrNotMatched
+	}
+	if len(raw) < 12 {
+		return nil, fmt.Errorf("fujifilm makernote too short length: %d, minimum: 12", len(raw))
+	}
+
+	mnHelper := helpers.ValueExtractor{
+		Source:    e.Source,
+		TiffStart: mnStart,
+		Endian:    binary.LittleEndian,
+		Context:   e.Context,
+	}
+	ifdStart := mnStart + int(binary.LittleEndian.Uint32(raw[8:12]))
+
+	parsed := &FujifilmMakerNote{}
+	lens := make([]float64, 4)
+	haveLens := false
+
+	_, err = mnHelper.WalkIFD("MakerNotes", ifdStart, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case FujifilmVersion:
+			parsed.MakerNoteVersion = value.String()
+		case FujifilmInternalSerialNumber:
+			parsed.InternalSerialNumber = value.String()
+		case FujifilmQuality:
+			parsed.Quality = value.String()
+		case FujifilmSaturation:
+			parsed.Saturation = lookupFormat(fujifilmSaturation, value.Int(0), "Unknown (0x%x)")
+		case FujifilmFilmMode:
+			parsed.FilmMode = lookupFormat(fujifilmFilmMode, value.Int(0), "Unknown (0x%x)")
+		case FujifilmMinFocalLength, FujifilmMaxFocalLength, FujifilmMaxApertureAtMinFocal, FujifilmMaxApertureAtMaxFocal:
+			lens[entry.Tag-FujifilmMinFocalLength] = value.Float(0)
+			haveLens = true
+		case FujifilmImageCount:
+			// The top bit is set by some models and is not part of the count
+			parsed.ImageCount = uint16(value.Int(0)) & 0x7fff
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Monochrome film simulations are recorded as a saturation setting rather than a film mode
+	if parsed.FilmMode == "" {
+		switch parsed.Saturation {
+		case "None (B&W)", "B&W Red Filter", "B&W Yellow Filter", "B&W Green Filter", "B&W Sepia",
+			"Acros", "Acros Red Filter", "Acros Yellow Filter", "Acros Green Filter":
+			parsed.FilmMode = parsed.Saturation
+		}
+	}
+	if haveLens && lens[0] != 0 {
+		parsed.Lens = lensSpec(lens)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,55 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestFujifilmParser(t *testing.T) {
+	le := binary.LittleEndian
+	rational := func(num, den uint32) []byte {
+		return le.AppendUint32(le.AppendUint32(nil, num), den)
+	}
+
+	// The IFD follows the 12 byte header, and offsets count from the start of the MakerNote
+	note := append([]byte("FUJIFILM\x0c\x00\x00\x00"), makerNoteIFD(le, 12, []noteEntry{
+		{0x0000, 7, 4, []byte("0130")},
+		{0x0010, 2, 12, []byte("FF02B1234567")},
+		{0x1000, 2, 8, []byte("NORMAL \x00")},
+		{0x1003, 3, 1, le.AppendUint16(nil, 0x500)},
+		{0x1404, 5, 1, rational(18, 1)},
+		{0x1405, 5, 1, rational(55, 1)},
+		{0x1406, 5, 1, rational(28, 10)},
+		{0x1407, 5, 1, rational(40, 10)},
+		{0x1438, 3, 1, le.AppendUint16(nil, 0x8123)},
+	})...)
+
+	e, entry := makerNoteExtractor(note)
+	e.Context = &helpers.ParseContext{}
+	detection, parsed, err := DetectAndParse(e, entry, "FUJIFILM")
+	if err != nil || detection.Manufacturer != "Fujifilm" {
+		t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+	}
+	if len(e.Context.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+	}
+
+	fujifilm, ok := parsed.(*FujifilmMakerNote)
+	if !ok {
+		t.Fatalf("parsed is %T, want *FujifilmMakerNote", parsed)
+	}
+	want := FujifilmMakerNote{
+		MakerNoteVersion:     "0130",
+		InternalSerialNumber: "FF02B1234567",
+		Quality:              "NORMAL ",
+		Saturation:           "Acros",
+		FilmMode:             "Acros",
+		Lens:                 "18-55mm f/2.8-4",
+		ImageCount:           0x123,
+	}
+	if *fujifilm != want {
+		t.Errorf("parsed = %+v, want %+v", *fujifilm, want)
+	}
+}
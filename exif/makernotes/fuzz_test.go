@@ -0,0 +1,271 @@
+package makernotes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+	"github.com/ZanyLeonic/exif-reader/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// appleMakerNote builds an Apple MakerNote with the given entries, each value stored inline
+func appleMakerNote(entries [][3]uint32) []byte {
+	out := []byte("Apple iOS\x00\x00\x01MM")
+	out = binary.BigEndian.AppendUint16(out, uint16(len(entries)))
+	for _, entry := range entries {
+		out = binary.BigEndian.AppendUint16(out, uint16(entry[0]))
+		out = binary.BigEndian.AppendUint16(out, uint16(entry[1]))
+		out = binary.BigEndian.AppendUint32(out, 1)
+		out = binary.BigEndian.AppendUint32(out, entry[2])
+	}
+	return binary.BigEndian.AppendUint32(out, 0)
+}
+
+// makerNoteExtractor places the MakerNote after a single IFD entry pointing at it, as it would be
+// found in the Exif IFD
+func makerNoteExtractor(makerNote []byte) (*helpers.ValueExtractor, helpers.IFDEntry) {
+	data := make([]byte, 12, 12+len(makerNote))
+	binary.BigEndian.PutUint16(data[0:], 0x927c)
+	binary.BigEndian.PutUint16(data[2:], helpers.TypeUndefined)
+	binary.BigEndian.PutUint32(data[4:], uint32(len(makerNote)))
+	binary.BigEndian.PutUint32(data[8:], 12)
+	data = append(data, makerNote...)
+
+	entry, _ := helpers.ParseIFDEntry(helpers.NewBytesSource(data), 0, binary.BigEndian)
+	return &helpers.ValueExtractor{Source: helpers.NewBytesSource(data), TiffStart: 0, Endian: binary.BigEndian}, entry
+}
+
+// noteEntry is an IFD entry for makerNoteIFD
+type noteEntry struct {
+	tag      uint16
+	dataType uint16
+	count    uint32
+	value    []byte
+}
+
+// makerNoteIFD lays out an IFD with its longer values after it. base is the offset the
+// value offsets are relative to, as seen from the start of the IFD.
+func makerNoteIFD(order binary.AppendByteOrder, base int, entries []noteEntry) []byte {
+	out := order.AppendUint16(nil, uint16(len(entries)))
+	dataStart := 2 + len(entries)*12 + 4
+	var blob []byte
+	for _, entry := range entries {
+		out = order.AppendUint16(out, entry.tag)
+		out = order.AppendUint16(out, entry.dataType)
+		out = order.AppendUint32(out, entry.count)
+		if len(entry.value) <= 4 {
+			out = append(out, append(entry.value, make([]byte, 4-len(entry.value))...)...)
+			continue
+		}
+		out = order.AppendUint32(out, uint32(base+dataStart+len(blob)))
+		blob = append(blob, entry.value...)
+	}
+	out = order.AppendUint32(out, 0)
+	return append(out, blob...)
+}
+
+// canonMakerNote builds a Canon MakerNote as placed by makerNoteExtractor, with its footer
+func canonMakerNote(entries []noteEntry) []byte {
+	note := makerNoteIFD(binary.BigEndian, 12, entries)
+	note = append(note, "MM\x00*"...)
+	return binary.BigEndian.AppendUint32(note, 12)
+}
+
+// nikonMakerNote builds a type 3 Nikon MakerNote, whose offsets are relative to its own TIFF header
+func nikonMakerNote(entries []noteEntry) []byte {
+	note := []byte("Nikon\x00\x02\x10\x00\x00MM\x00*\x00\x00\x00\x08")
+	return append(note, makerNoteIFD(binary.BigEndian, 8, entries)...)
+}
+
+// ptr returns a pointer to v, for the optional fields of typed MakerNotes
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// shorts encodes big-endian 16-bit values
+func shorts(values ...int16) []byte {
+	var out []byte
+	for _, v := range values {
+		out = binary.BigEndian.AppendUint16(out, uint16(v))
+	}
+	return out
+}
+
+func FuzzDetectAndParse(f *testing.F) {
+	f.Add(appleMakerNote([][3]uint32{{0x0001, 9, 14}, {0x0004, 9, 1}, {0x000a, 9, 3}, {0x0014, 9, 10}}), "Apple")
+	f.Add(appleMakerNote(nil), "Apple")
+	f.Add(appleRunTimeNote(runTimePlist(1, 3_723_500_000_000, 0, 1_000_000_000)), "Apple")
+	f.Add([]byte("Apple iOS\x00\x00\x01II\xff\xff"), "Apple")
+	f.Add([]byte("Apple iOS"), "Apple")
+	f.Add(canonMakerNote([]noteEntry{
+		{0x0001, 3, 6, shorts(12, 2, 0, 3, 0, 1)},
+		{0x0007, 2, 8, []byte("1.0.0\x00\x00\x00")},
+	}), "Canon")
+	f.Add(nikonMakerNote([]noteEntry{
+		{0x001d, 2, 8, []byte("4012345\x00")},
+		{0x0098, 7, 8, []byte("0204\x01\x02\x03\x04")},
+		{0x00a7, 4, 1, []byte{0, 0, 0x30, 0x39}},
+	}), "NIKON CORPORATION")
+	f.Add(append([]byte("SONY DSC \x00\x00\x00"), makerNoteIFD(binary.BigEndian, 24, []noteEntry{
+		{0x0102, 4, 1, []byte{0, 0, 0, 2}},
+		{0x9050, 7, 8, []byte{0x07, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}},
+	})...), "SONY")
+	f.Add(append([]byte("FUJIFILM\x0c\x00\x00\x00"), makerNoteIFD(binary.LittleEndian, 12, []noteEntry{
+		{0x1401, 3, 1, []byte{0x00, 0x06}},
+	})...), "FUJIFILM")
+	f.Add(olympusMakerNote("OM SYSTEM\x00\x00\x00II\x04\x00"), "OM Digital Solutions")
+	f.Add(append([]byte("Panasonic\x00\x00\x00"), makerNoteIFD(binary.BigEndian, 24, []noteEntry{
+		{0x0089, 3, 1, shorts(2)},
+	})...), "Panasonic")
+	f.Add(makerNoteIFD(binary.BigEndian, 12, []noteEntry{
+		{0x0001, 2, 4, []byte("DJI\x00")},
+		{0x0009, 11, 1, []byte{0xc2, 0x34, 0x00, 0x00}},
+	}), "DJI")
+
+	f.Fuzz(func(t *testing.T, makerNote []byte, cameraMake string) {
+		e, entry := makerNoteExtractor(makerNote)
+		if detection, parsed, err := DetectAndParse(e, entry, cameraMake); err == nil && (parsed == nil || detection.Reason == "") {
+			t.Fatalf("DetectAndParse succeeded without a result: %+v %v", detection, parsed)
+		}
+		if parsed, err := (&AppleParser{}).Parse(e, entry, cameraMake); err == nil && parsed == nil {
+			t.Fatal("AppleParser succeeded without a result")
+		}
+		// Whatever the bytes, the fallback must not panic
+		ParseGeneric(e, entry)
+	})
+}
+
+// exampleHDRPlus returns the encrypted HDR+ MakerNote of example.jpg, if the file is available
+func exampleHDRPlus() []byte {
+	data, err := os.ReadFile(filepath.Join("..", "..", "example.jpg"))
+	if err != nil {
+		return nil
+	}
+
+	packet, err := helpers.ExtractXMPData(data)
+	if err != nil {
+		return nil
+	}
+	xmp, _ := helpers.DecodeXMPMeta([]byte(packet))
+	ext, err := helpers.ExtractExtXMPData(data, xmp.RDF.Description.HasExtendedXMP)
+	if err != nil {
+		return nil
+	}
+	extXmp, _ := helpers.DecodeXMPMeta([]byte(ext))
+
+	encrypted, err := base64.StdEncoding.DecodeString(helpers.SanitizeBase64String(extXmp.RDF.Description.HdrPlusMakerNote))
+	if err != nil || len(encrypted) <= 5 {
+		return nil
+	}
+	return encrypted[5:]
+}
+
+func FuzzDecryptHDRPlus(f *testing.F) {
+	if encrypted := exampleHDRPlus(); encrypted != nil {
+		f.Add(encrypted)
+		f.Add(encrypted[:len(encrypted)/2])
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0x1f, 0x8b, 0x08, 0x00})
+
+	f.Fuzz(func(t *testing.T, encrypted []byte) {
+		decrypted, err := DecryptHDRPBytes(encrypted)
+		if err != nil {
+			return
+		}
+		if len(decrypted) != len(encrypted) {
+			t.Fatalf("decrypted length %d, want %d", len(decrypted), len(encrypted))
+		}
+
+		// The cipher is an XOR stream, so applying it twice restores the input
+		if again, err := DecryptHDRPBytes(decrypted); err != nil || !bytes.Equal(again, encrypted) {
+			t.Fatalf("cipher is not symmetric: %v", err)
+		}
+
+		protoBytes, err := ReadGzipContent(decrypted)
+		if err == nil && len(protoBytes) > maxHDRPlusSize {
+			t.Fatalf("decompressed %d bytes, over the %d byte cap", len(protoBytes), maxHDRPlusSize)
+		}
+	})
+}
+
+// samsungBlock is one data block for samsungTrailer
+type samsungBlock struct {
+	blockType uint16
+	name      string
+	data      []byte
+}
+
+// samsungTrailer appends the blocks, a SEFH directory listing them and the SEFT footer to image
+func samsungTrailer(image []byte, blocks ...samsungBlock) []byte {
+	out := append([]byte(nil), image...)
+	var starts []int
+	for _, block := range blocks {
+		starts = append(starts, len(out))
+		out = append(out, 0, 0)
+		out = binary.LittleEndian.AppendUint16(out, block.blockType)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(block.name)))
+		out = append(out, block.name...)
+		out = append(out, block.data...)
+	}
+
+	dirStart := len(out)
+	out = append(out, "SEFH"...)
+	out = binary.LittleEndian.AppendUint32(out, 107)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(blocks)))
+	for i, block := range blocks {
+		out = append(out, 0, 0)
+		out = binary.LittleEndian.AppendUint16(out, block.blockType)
+		out = binary.LittleEndian.AppendUint32(out, uint32(dirStart-starts[i]))
+		out = binary.LittleEndian.AppendUint32(out, uint32(8+len(block.name)+len(block.data)))
+	}
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(out)-dirStart))
+	return append(out, "SEFT"...)
+}
+
+func FuzzParseSamsungTrailer(f *testing.F) {
+	f.Add(samsungTrailer([]byte{0xff, 0xd8, 0xff, 0xd9},
+		samsungBlock{0x0a01, "Image_UTC_Data", []byte("1727791132085")},
+		samsungBlock{0x0a30, "MotionPhoto_Data", []byte("\x00\x00\x00\x18ftypmp42")},
+	))
+	f.Add(samsungTrailer(nil))
+	f.Add([]byte("\x10\x00\x00\x00SEFT"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		trailer, err := ParseSamsungTrailer(helpers.NewBytesSource(data), nil)
+		if err != nil || trailer == nil {
+			return
+		}
+		for _, entry := range trailer.Entries {
+			if entry.Offset < 0 || entry.Length < 0 || entry.Offset+entry.Length > trailer.Offset {
+				t.Fatalf("entry %q at %d+%d lies outside the trailer at %d", entry.Name, entry.Offset, entry.Length, trailer.Offset)
+			}
+		}
+	})
+}
+
+func FuzzConvertHDRPlus(f *testing.F) {
+	if encrypted := exampleHDRPlus(); encrypted != nil {
+		if decrypted, err := DecryptHDRPBytes(encrypted); err == nil {
+			if protoBytes, err := ReadGzipContent(decrypted); err == nil {
+				f.Add(protoBytes)
+			}
+		}
+	}
+	f.Add([]byte{0x4a, 0x04, 0xa2, 0x02, 0x01, 0x08})
+	f.Add([]byte{0x5a, 0x02, 0x5a, 0x00})
+
+	f.Fuzz(func(t *testing.T, protoBytes []byte) {
+		var notes pb.GoogleHDRPlusMakerNote
+		// Like the reader, convert whatever was decoded before an error
+		_ = proto.Unmarshal(protoBytes, &notes)
+		if parsed := ConvertHDRPlusToMakerNote(&notes, protoBytes).Parsed; parsed == nil {
+			t.Fatal("no parsed MakerNote")
+		}
+	})
+}
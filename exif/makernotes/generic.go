@@ -0,0 +1,54 @@
+package makernotes
+
+import (
+	"fmt"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// ParseGeneric reads a MakerNote that no parser accepted as a bare IFD, the layout most manufacturers
+// use, with value offsets relative to the EXIF TIFF header. Values are keyed by their tag number, as
+// in "0x0001". It returns nil when the MakerNote does not look like an IFD: the entry count must fit
+// the MakerNote and every entry must have a TIFF type.
+//
+// Some manufacturers count offsets from the MakerNote instead, so values stored out of line may be
+// read from the wrong place. They are not claimed, to avoid reporting overlaps for them.
+func ParseGeneric(e *helpers.ValueExtractor, entry helpers.IFDEntry) helpers.GenericMakerNote {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil
+	}
+	raw := value.Bytes()
+	if len(raw) < 2 {
+		return nil
+	}
+
+	count := int(e.Endian.Uint16(raw))
+	if count == 0 || 2+count*12 > len(raw) {
+		return nil
+	}
+	for i := range count {
+		if helpers.TypeSize(e.Endian.Uint16(raw[2+i*12+2:])) == 0 {
+			return nil
+		}
+	}
+
+	ifd, err := e.ReadIFD("MakerNotes", value.Offset)
+	if err != nil && len(ifd.Entries) == 0 {
+		return nil
+	}
+
+	parsed := helpers.GenericMakerNote{}
+	for _, entry := range ifd.Entries {
+		value, err := e.Decode(entry)
+		e.Context.RecordTag("MakerNotes", entry, value, err)
+		if err != nil {
+			e.Context.Log().Debug("cannot decode MakerNote entry",
+				"tag", fmt.Sprintf("0x%04x", entry.Tag),
+				"error", err)
+			continue
+		}
+		parsed[fmt.Sprintf("0x%04x", uint16(entry.Tag))] = value.Value
+	}
+	return parsed
+}
@@ -0,0 +1,37 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestParseGeneric(t *testing.T) {
+	tests := []struct {
+		name string
+		note []byte
+		want helpers.GenericMakerNote
+	}{
+		{"bare IFD", makerNoteIFD(binary.BigEndian, 12, []noteEntry{
+			{0x0001, 3, 1, shorts(2)},
+			{0x0002, 2, 8, []byte("LX100M2\x00")},
+		}), helpers.GenericMakerNote{"0x0001": []uint16{2}, "0x0002": "LX100M2"}},
+		{"header", []byte("LEICA\x00\x00\x00\x00\x01\x00\x00"), nil},
+		{"entries past the end", makerNoteIFD(binary.BigEndian, 12, []noteEntry{{0x0001, 3, 1, shorts(2)}})[:12], nil},
+		{"unknown type", makerNoteIFD(binary.BigEndian, 12, []noteEntry{{0x0001, 99, 1, shorts(2)}}), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, entry := makerNoteExtractor(tt.note)
+			e.Context = &helpers.ParseContext{}
+			if got := ParseGeneric(e, entry); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseGeneric() = %#v, want %#v", got, tt.want)
+			}
+			if len(e.Context.Diagnostics) != 0 {
+				t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+			}
+		})
+	}
+}
@@ -8,113 +8,164 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log/slog"
+	"time"
 
 	"github.com/ZanyLeonic/exif-reader/exif/helpers"
 	"github.com/ZanyLeonic/exif-reader/pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-// ConvertHDRPlusToMakerNote converts a GoogleHDRPlusMakerNote protobuf to MakerNoteData
-func ConvertHDRPlusToMakerNote(notes *pb.GoogleHDRPlusMakerNote, rawData []byte) helpers.MakerNoteData {
-	parsed := make(map[string]interface{})
+// GoogleHDRPlusMakerNote A summary of the HDR+ MakerNote Google's camera app stores in XMP
+type GoogleHDRPlusMakerNote struct {
+	ImageInfo   *GoogleHDRPlusImageInfo `json:"imageInfo,omitempty"`
+	TimeLogText string                  `json:"timeLogText,omitempty"`
+	SummaryText string                  `json:"summaryText,omitempty"`
+	FrameCount  int32                   `json:"frameCount,omitempty"`
+	// CreateDate is when the burst was captured, in UTC
+	CreateDate time.Time                `json:"createDate,omitzero"`
+	DeviceInfo *GoogleHDRPlusDeviceInfo `json:"deviceInfo,omitempty"`
+	// UnknownFields holds the fields outside the schema in pb, such as the per-frame capture data
+	UnknownFields []GoogleHDRPlusUnknownField `json:"unknownFields,omitempty"`
+}
 
-	if notes.GetImageInfo() != nil {
-		imageInfo := notes.GetImageInfo()
-		imageData := make(map[string]interface{})
+// GoogleHDRPlusUnknownField A field outside the known HDR+ schema, kept as stored. Message names the
+// known message holding it and Number is its field number there. Value is a uint64 for varint and
+// 64-bit fields, a uint32 for 32-bit fields and the raw bytes of length-delimited fields and groups,
+// which may hold text, packed values or a nested message.
+type GoogleHDRPlusUnknownField struct {
+	Message  string      `json:"message"`
+	Number   int32       `json:"number"`
+	WireType int8        `json:"wireType"`
+	Value    interface{} `json:"value"`
+}
 
-		if imageInfo.GetImageName() != "" {
-			imageData["imageName"] = imageInfo.GetImageName()
-		}
-		if len(imageInfo.GetImageData()) > 0 {
-			imageData["imageDataSize"] = len(imageInfo.GetImageData())
-		}
+// GoogleHDRPlusImageInfo The name and size of the image the HDR+ MakerNote describes
+type GoogleHDRPlusImageInfo struct {
+	ImageName     string `json:"imageName,omitempty"`
+	ImageDataSize int    `json:"imageDataSize,omitempty"`
+}
 
-		if len(imageData) > 0 {
-			parsed["imageInfo"] = imageData
-		}
-	}
+// GoogleHDRPlusDeviceInfo The device and camera app that took an HDR+ photo, and its sensor's limits
+type GoogleHDRPlusDeviceInfo struct {
+	Make             string  `json:"make,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Codename         string  `json:"codename,omitempty"`
+	HardwareRevision string  `json:"hardwareRevision,omitempty"`
+	HDRPSoftware     string  `json:"hdrpSoftware,omitempty"`
+	AndroidRelease   string  `json:"androidRelease,omitempty"`
+	SoftwareDate     int64   `json:"softwareDate,omitempty"`
+	Application      string  `json:"application,omitempty"`
+	AppVersion       string  `json:"appVersion,omitempty"`
+	ExposureTimeMin  float32 `json:"exposureTimeMin,omitempty"`
+	ExposureTimeMax  float32 `json:"exposureTimeMax,omitempty"`
+	IsoMin           float32 `json:"isoMin,omitempty"`
+	IsoMax           float32 `json:"isoMax,omitempty"`
+	MaxAnalogISO     float32 `json:"maxAnalogIso,omitempty"`
+}
 
-	if notes.GetTimeLogText() != "" {
-		timeLog := notes.GetTimeLogText()
-		parsed["timeLogText"] = timeLog
-	}
+func (g *GoogleHDRPlusMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(g)
+}
 
-	if notes.GetSummaryText() != "" {
-		summary := notes.GetSummaryText()
-		parsed["summaryText"] = summary
+// ConvertHDRPlusToMakerNote converts a GoogleHDRPlusMakerNote protobuf to MakerNoteData
+func ConvertHDRPlusToMakerNote(notes *pb.GoogleHDRPlusMakerNote, rawData []byte) helpers.MakerNoteData {
+	parsed := &GoogleHDRPlusMakerNote{
+		TimeLogText: notes.GetTimeLogText(),
+		SummaryText: notes.GetSummaryText(),
+		FrameCount:  notes.GetFrameCount().GetFrameCount(),
 	}
 
-	if notes.GetFrameCount() != nil {
-		frameInfo := notes.GetFrameCount()
-		parsed["frameCount"] = frameInfo.GetFrameCount()
+	// The create date is a timestamp of seconds and nanoseconds since the Unix epoch
+	if createDate := notes.GetFrameCount().GetCreateDateInfo(); createDate.GetCreateDate() != 0 {
+		parsed.CreateDate = time.Unix(createDate.GetCreateDate(), int64(createDate.GetCreateDateNanos())).UTC()
 	}
 
-	if notes.GetDeviceInfo() != nil {
-		deviceInfo := notes.GetDeviceInfo()
-		deviceData := make(map[string]interface{})
+	parsed.UnknownFields = hdrPlusUnknownFields(notes.ProtoReflect())
 
-		if deviceInfo.GetDeviceMake() != "" {
-			deviceData["make"] = deviceInfo.GetDeviceMake()
-		}
-		if deviceInfo.GetDeviceModel() != "" {
-			deviceData["model"] = deviceInfo.GetDeviceModel()
-		}
-		if deviceInfo.GetDeviceCodename() != "" {
-			deviceData["codename"] = deviceInfo.GetDeviceCodename()
-		}
-		if deviceInfo.GetDeviceHardwareRevision() != "" {
-			deviceData["hardwareRevision"] = deviceInfo.GetDeviceHardwareRevision()
-		}
-		if deviceInfo.GetHDRPSoftware() != "" {
-			deviceData["hdrpSoftware"] = deviceInfo.GetHDRPSoftware()
-		}
-		if deviceInfo.GetAndroidRelease() != "" {
-			deviceData["androidRelease"] = deviceInfo.GetAndroidRelease()
-		}
-		if deviceInfo.GetSoftwareDate() != 0 {
-			deviceData["softwareDate"] = deviceInfo.GetSoftwareDate()
-		}
-		if deviceInfo.GetApplication() != "" {
-			deviceData["application"] = deviceInfo.GetApplication()
-		}
-		if deviceInfo.GetAppVersion() != "" {
-			deviceData["appVersion"] = deviceInfo.GetAppVersion()
+	if imageInfo := notes.GetImageInfo(); imageInfo != nil {
+		parsed.ImageInfo = &GoogleHDRPlusImageInfo{
+			ImageName:     imageInfo.GetImageName(),
+			ImageDataSize: len(imageInfo.GetImageData()),
 		}
+	}
 
-		if deviceInfo.GetExposureTimeInfo() != nil {
-			expInfo := deviceInfo.GetExposureTimeInfo()
-			if expInfo.GetExposureTimeMin() != 0 {
-				deviceData["exposureTimeMin"] = expInfo.GetExposureTimeMin()
-			}
-			if expInfo.GetExposureTimeMax() != 0 {
-				deviceData["exposureTimeMax"] = expInfo.GetExposureTimeMax()
-			}
+	if deviceInfo := notes.GetDeviceInfo(); deviceInfo != nil {
+		parsed.DeviceInfo = &GoogleHDRPlusDeviceInfo{
+			Make:             deviceInfo.GetDeviceMake(),
+			Model:            deviceInfo.GetDeviceModel(),
+			Codename:         deviceInfo.GetDeviceCodename(),
+			HardwareRevision: deviceInfo.GetDeviceHardwareRevision(),
+			HDRPSoftware:     deviceInfo.GetHDRPSoftware(),
+			AndroidRelease:   deviceInfo.GetAndroidRelease(),
+			SoftwareDate:     deviceInfo.GetSoftwareDate(),
+			Application:      deviceInfo.GetApplication(),
+			AppVersion:       deviceInfo.GetAppVersion(),
+			ExposureTimeMin:  deviceInfo.GetExposureTimeInfo().GetExposureTimeMin(),
+			ExposureTimeMax:  deviceInfo.GetExposureTimeInfo().GetExposureTimeMax(),
+			IsoMin:           deviceInfo.GetIsoInfo().GetIsoMin(),
+			IsoMax:           deviceInfo.GetIsoInfo().GetIsoMax(),
+			MaxAnalogISO:     deviceInfo.GetMaxAnalogISO(),
 		}
+	}
+
+	return helpers.MakerNoteData{
+		Raw:          rawData,
+		Manufacturer: "Google HDR+",
+		MatchedBy:    "XMP HdrPlusMakernote",
+		Parsed:       parsed,
+	}
+}
 
-		if deviceInfo.GetIsoInfo() != nil {
-			isoInfo := deviceInfo.GetIsoInfo()
-			if isoInfo.GetIsoMin() != 0 {
-				deviceData["isoMin"] = isoInfo.GetIsoMin()
-			}
-			if isoInfo.GetIsoMax() != 0 {
-				deviceData["isoMax"] = isoInfo.GetIsoMax()
-			}
+// hdrPlusUnknownFields collects the unknown fields of m and of the known messages within it
+func hdrPlusUnknownFields(m protoreflect.Message) []GoogleHDRPlusUnknownField {
+	var fields []GoogleHDRPlusUnknownField
+	message := string(m.Descriptor().Name())
+
+	unknown := m.GetUnknown()
+	for len(unknown) > 0 {
+		number, wireType, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			break
 		}
+		unknown = unknown[n:]
 
-		if deviceInfo.GetMaxAnalogISO() != 0 {
-			deviceData["maxAnalogIso"] = deviceInfo.GetMaxAnalogISO()
+		n = protowire.ConsumeFieldValue(number, wireType, unknown)
+		if n < 0 {
+			break
 		}
 
-		if len(deviceData) > 0 {
-			parsed["deviceInfo"] = deviceData
+		var value interface{}
+		switch wireType {
+		case protowire.VarintType:
+			value, _ = protowire.ConsumeVarint(unknown)
+		case protowire.Fixed32Type:
+			value, _ = protowire.ConsumeFixed32(unknown)
+		case protowire.Fixed64Type:
+			value, _ = protowire.ConsumeFixed64(unknown)
+		case protowire.BytesType:
+			raw, _ := protowire.ConsumeBytes(unknown)
+			value = bytes.Clone(raw)
+		default:
+			value = bytes.Clone(unknown[:n])
 		}
+		unknown = unknown[n:]
+
+		fields = append(fields, GoogleHDRPlusUnknownField{
+			Message:  message,
+			Number:   int32(number),
+			WireType: int8(wireType),
+			Value:    value,
+		})
 	}
 
-	return helpers.MakerNoteData{
-		Raw:          rawData,
-		Manufacturer: "Google HDR+",
-		Parsed:       parsed,
-	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+			fields = append(fields, hdrPlusUnknownFields(v.Message())...)
+		}
+		return true
+	})
+	return fields
 }
 
 // DecryptHDRPBytes implements the custom 64-bit XOR cipher used by Google, encrypting their MakerNote (ported from Exiftool)
@@ -221,24 +272,31 @@ func multiply64(hi, lo uint32) (uint32, uint32) {
 	return newHi, newLo
 }
 
+// ReadGzipContent decompresses the decrypted HDR+ payload, falling back to raw DEFLATE when the gzip
+// header is damaged and keeping whatever a truncated stream yields. Warnings go to the default logger.
 func ReadGzipContent(decrypted []byte) ([]byte, error) {
+	return ReadGzipContentWithContext(decrypted, nil)
+}
+
+// ReadGzipContentWithContext is ReadGzipContent reporting truncation and corruption through ctx
+func ReadGzipContentWithContext(decrypted []byte, ctx *helpers.ParseContext) ([]byte, error) {
 	reader, err := gzip.NewReader(bytes.NewReader(decrypted))
 	if err != nil {
 		// if the gunzip header is corrupted, attempt to raw inflate
-		slog.Warn("gzip.NewReader failed, attempting raw inflate", "error", err)
+		ctx.Log().Debug("gzip.NewReader failed, attempting raw inflate", "error", err)
 
 		protoBytes, err := tryRawInflate(decrypted)
 		if err != nil || len(protoBytes) == 0 {
 			return nil, fmt.Errorf("both gzip and raw inflate failed: %w", err)
 		}
-		slog.Debug("Successfully inflated using raw deflate", "size", len(protoBytes))
+		ctx.Log().Debug("Successfully inflated using raw deflate", "size", len(protoBytes))
 
 		return protoBytes, nil
 	}
 	defer reader.Close()
 
 	// Read all available data, even if we hit EOF early
-	protoBytes, err := io.ReadAll(reader)
+	protoBytes, err := io.ReadAll(io.LimitReader(reader, maxHDRPlusSize))
 
 	// Check if we got usable data despite errors
 	if len(protoBytes) == 0 && err != nil {
@@ -247,16 +305,19 @@ func ReadGzipContent(decrypted []byte) ([]byte, error) {
 
 	// Like ExifTool, treat EOF-related errors as warnings if we got data
 	if err != nil && err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
-		slog.Warn("gzip ReadAll encountered error, using partial data", "error", err, "bytesRead", len(protoBytes))
+		ctx.Warn(helpers.DiagDecodeFailed, "MakerNotes", -1, "HDR+ gzip stream is corrupt, using partial data: "+err.Error(), "bytesRead", len(protoBytes))
 	} else if errors.Is(err, io.ErrUnexpectedEOF) {
-		slog.Warn("gzip stream truncated (unexpected EOF), using available data", "bytesRead", len(protoBytes))
+		ctx.Warn(helpers.DiagTruncated, "MakerNotes", -1, "HDR+ gzip stream truncated, using available data", "bytesRead", len(protoBytes))
 	}
 
-	slog.Debug("Decompressed protobuf data", "size", len(protoBytes))
+	ctx.Log().Debug("Decompressed protobuf data", "size", len(protoBytes))
 
 	return protoBytes, nil
 }
 
+// maxHDRPlusSize caps the decompressed protobuf so a crafted stream cannot exhaust memory
+const maxHDRPlusSize = helpers.MaxBlobSize
+
 // tryRawInflate attempts to decompress data using raw DEFLATE format
 // This is more permissive than gzip and can handle truncated streams
 // Similar to how Compress::Raw::Zlib handles partial data in Perl
@@ -267,7 +328,7 @@ func tryRawInflate(data []byte) ([]byte, error) {
 
 	// Read as much as possible, even if we hit EOF
 	var result bytes.Buffer
-	_, err := io.Copy(&result, reader)
+	_, err := io.Copy(&result, io.LimitReader(reader, maxHDRPlusSize))
 
 	if err != nil && err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
 		return nil, fmt.Errorf("raw inflate failed: %w", err)
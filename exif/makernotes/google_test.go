@@ -0,0 +1,53 @@
+package makernotes
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZanyLeonic/exif-reader/pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConvertHDRPlusToMakerNote(t *testing.T) {
+	// Fields outside the schema at the top level and within the frame information
+	frames := &pb.FrameInfo{
+		FrameCount:     6,
+		CreateDateInfo: &pb.CreateDateInfo{CreateDate: 1727791133, CreateDateNanos: 500_000_000},
+	}
+	frames.ProtoReflect().SetUnknown(protowire.AppendBytes(protowire.AppendTag(nil, 5, protowire.BytesType), []byte{1, 2}))
+	notes := &pb.GoogleHDRPlusMakerNote{
+		FrameCount: frames,
+		DeviceInfo: &pb.DeviceInfo{DeviceModel: "Pixel 8 Pro", SoftwareDate: 1723195942000},
+	}
+	notes.ProtoReflect().SetUnknown(protowire.AppendFixed32(protowire.AppendTag(nil, 20, protowire.Fixed32Type), 0x3f800000))
+	encoded, err := proto.Marshal(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded pb.GoogleHDRPlusMakerNote
+	if err := proto.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	parsed, ok := ConvertHDRPlusToMakerNote(&decoded, nil).Parsed.(*GoogleHDRPlusMakerNote)
+	if !ok {
+		t.Fatal("parsed MakerNote is not a GoogleHDRPlusMakerNote")
+	}
+
+	if want := time.Date(2024, 10, 1, 13, 58, 53, 500_000_000, time.UTC); !parsed.CreateDate.Equal(want) {
+		t.Errorf("CreateDate = %v, want %v", parsed.CreateDate, want)
+	}
+	if parsed.DeviceInfo == nil || parsed.DeviceInfo.SoftwareDate != 1723195942000 {
+		t.Errorf("DeviceInfo = %+v, want SoftwareDate 1723195942000", parsed.DeviceInfo)
+	}
+
+	want := []GoogleHDRPlusUnknownField{
+		{"GoogleHDRPlusMakerNote", 20, int8(protowire.Fixed32Type), uint32(0x3f800000)},
+		{"FrameInfo", 5, int8(protowire.BytesType), []byte{1, 2}},
+	}
+	if !reflect.DeepEqual(parsed.UnknownFields, want) {
+		t.Errorf("UnknownFields = %v, want %v", parsed.UnknownFields, want)
+	}
+}
@@ -0,0 +1,116 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestNikonParser(t *testing.T) {
+	const serial, shutterCount = 4012345, 12345
+
+	// The cipher is an XOR stream, so encrypting is the same as decrypting
+	lensData := []byte("0204\x40\x24\x00\x00\x10\x00\x50\x48\x9c\x48\x30\x60\x24\x24\x4e")
+	colorBalance := make([]byte, 34)
+	copy(colorBalance, "0205")
+	for i, level := range []uint16{500, 256, 256, 400} {
+		binary.BigEndian.PutUint16(colorBalance[18+i*2:], level)
+	}
+	shotInfo := []byte("02101.10\x00\x00\x00\x00")
+
+	note := nikonMakerNote([]noteEntry{
+		{0x0001, 7, 4, []byte("0211")},
+		{0x0002, 3, 2, shorts(0, 200)},
+		{0x001d, 2, 8, []byte("4012345\x00")},
+		{0x001f, 7, 8, []byte("0100\x01\x00\x00\x00")},
+		{0x0025, 7, 14, []byte("\x48\x00\x00\x00\x00\x00\x48\x00\x00\x00\x01\x01\x00\x00")},
+		{0x0083, 1, 1, []byte{0x0e}},
+		{0x0091, 7, uint32(len(shotInfo)), DecryptNikonBytes(shotInfo, serial&0xff, shutterCount, 4)},
+		{0x0097, 7, uint32(len(colorBalance)), DecryptNikonBytes(colorBalance, serial&0xff, shutterCount, 4)},
+		{0x0098, 7, uint32(len(lensData)), DecryptNikonBytes(lensData, serial&0xff, shutterCount, 4)},
+		{0x00a7, 4, 1, binary.BigEndian.AppendUint32(nil, shutterCount)},
+	})
+
+	e, entry := makerNoteExtractor(note)
+	e.Context = &helpers.ParseContext{}
+	detection, parsed, err := DetectAndParse(e, entry, "NIKON CORPORATION")
+	if err != nil || detection.Manufacturer != "Nikon" {
+		t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+	}
+	if len(e.Context.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+	}
+
+	nikon, ok := parsed.(*NikonMakerNote)
+	if !ok {
+		t.Fatalf("parsed is %T, want *NikonMakerNote", parsed)
+	}
+	want := NikonMakerNote{
+		MakerNoteType:         3,
+		MakerNoteVersion:      "0211",
+		ISO:                   200,
+		SerialNumber:          "4012345",
+		ShutterCount:          shutterCount,
+		VRInfoVersion:         "0100",
+		VibrationReduction:    "On",
+		VRMode:                "Normal",
+		ISOInfo:               200,
+		ISOExpansion:          "Off",
+		ISO2:                  200,
+		ISOExpansion2:         "Hi 0.3",
+		ShotInfoVersion:       "0210",
+		FirmwareVersion:       "1.10",
+		ColorBalanceVersion:   "0205",
+		WBRGGBLevels:          []uint16{500, 256, 256, 400},
+		LensType:              "D G VR",
+		LensDataVersion:       "0204",
+		ExitPupilPosition:     32,
+		AFAperture:            2.8,
+		FocusPosition:         "0x10",
+		FocusDistance:         1,
+		FocalLength:           40,
+		LensIDNumber:          0x9c,
+		LensFStops:            6,
+		MinFocalLength:        20,
+		MaxFocalLength:        80,
+		MaxApertureAtMinFocal: 2.8,
+		MaxApertureAtMaxFocal: 2.8,
+		MCUVersion:            0x4e,
+	}
+	if !reflect.DeepEqual(*nikon, want) {
+		t.Errorf("parsed = %+v, want %+v", *nikon, want)
+	}
+}
+
+func TestNikonParserWithoutShutterCount(t *testing.T) {
+	e, entry := makerNoteExtractor(nikonMakerNote([]noteEntry{
+		{0x0098, 7, 8, []byte("0204\x01\x02\x03\x04")},
+	}))
+	e.Context = &helpers.ParseContext{}
+	_, parsed, err := DetectAndParse(e, entry, "NIKON CORPORATION")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.(*NikonMakerNote).LensDataVersion != "" {
+		t.Error("LensData decoded without a key")
+	}
+	if len(e.Context.Diagnostics) != 1 || e.Context.Diagnostics[0].Code != helpers.DiagUnsupported {
+		t.Errorf("want one unsupported diagnostic, got %v", e.Context.Diagnostics)
+	}
+}
+
+func TestNikonType2RequiresMake(t *testing.T) {
+	note := makerNoteIFD(binary.BigEndian, 12, []noteEntry{{0x0002, 3, 2, shorts(0, 400)}})
+	e, entry := makerNoteExtractor(note)
+	e.Context = &helpers.ParseContext{}
+
+	if _, err := (&NikonParser{}).Parse(e, entry, "Canon"); err != ErrNotMatched {
+		t.Errorf("bare IFD from another make: got %v, want ErrNotMatched", err)
+	}
+	parsed, err := (&NikonParser{}).Parse(e, entry, "NIKON")
+	if nikon, ok := parsed.(*NikonMakerNote); err != nil || !ok || nikon.ISO != 400 || nikon.MakerNoteType != 2 {
+		t.Errorf("type 2 note: %v, %v", parsed, err)
+	}
+}
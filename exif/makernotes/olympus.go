@@ -0,0 +1,178 @@
+package makernotes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Olympus MakerNote Tags
+const (
+	OlympusCameraType     helpers.Tag = 0x0207
+	OlympusEquipment      helpers.Tag = 0x2010
+	OlympusCameraSettings helpers.Tag = 0x2020
+)
+
+// Olympus Equipment Tags
+const (
+	OlympusSerialNumber         helpers.Tag = 0x0101
+	OlympusInternalSerialNumber helpers.Tag = 0x0102
+	OlympusBodyFirmwareVersion  helpers.Tag = 0x0104
+	OlympusLensType             helpers.Tag = 0x0201
+	OlympusLensSerialNumber     helpers.Tag = 0x0202
+	OlympusLensModel            helpers.Tag = 0x0203
+	OlympusLensFirmwareVersion  helpers.Tag = 0x0204
+)
+
+// Olympus CameraSettings Tags
+const (
+	OlympusPictureMode helpers.Tag = 0x0520
+)
+
+var olympusPictureMode = map[int64]string{
+	1: "Vivid", 2: "Natural", 3: "Muted", 4: "Portrait", 5: "i-Enhance", 6: "e-Portrait", 7: "Color Creator",
+	8: "Underwater", 9: "Color Profile 1", 10: "Color Profile 2", 11: "Color Profile 3",
+	12: "Monochrome Profile 1", 13: "Monochrome Profile 2", 14: "Monochrome Profile 3", 17: "Art Mode",
+	18: "Monochrome Profile 4", 256: "Monotone", 512: "Sepia",
+}
+
+// OlympusMakerNote The values decoded from an Olympus or OM Digital Solutions MakerNote and its
+// Equipment and CameraSettings IFDs
+type OlympusMakerNote struct {
+	CameraType           string `json:"cameraType,omitempty"`
+	SerialNumber         string `json:"serialNumber,omitempty"`
+	InternalSerialNumber string `json:"internalSerialNumber,omitempty"`
+	BodyFirmwareVersion  string `json:"bodyFirmwareVersion,omitempty"`
+	LensType             string `json:"lensType,omitempty"`
+	LensSerialNumber     string `json:"lensSerialNumber,omitempty"`
+	LensModel            string `json:"lensModel,omitempty"`
+	LensFirmwareVersion  string `json:"lensFirmwareVersion,omitempty"`
+	PictureMode          string `json:"pictureMode,omitempty"`
+}
+
+func (o *OlympusMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(o)
+}
+
+type OlympusParser struct{}
+
+func (p *OlympusParser) Manufacturer() string {
+	return "Olympus"
+}
+
+// Parse decodes the Olympus and OM Digital Solutions MakerNote layouts. "OLYMPUS\0" and "OM SYSTEM\0"
+// notes carry their own byte order mark, and value offsets relative to the start of the MakerNote.
+// The older "OLYMP\0" notes use the byte order of, and offsets relative to, the EXIF TIFF header.
+func (p *OlympusParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	raw := value.Bytes()
+	mnStart := value.Offset
+
+	mnHelper := helpers.ValueExtractor{
+		Source:    e.Source,
+		TiffStart: e.TiffStart,
+		Endian:    e.Endian,
+		Context:   e.Context,
+	}
+
+	var ifdStart int
+	switch {
+	case bytes.HasPrefix(raw, []byte("OLYMPUS\x00")), bytes.HasPrefix(raw, []byte("OM SYSTEM\x00")):
+		// The byte order mark follows the padded signature, and the IFD follows it and a version
+		bom := 8
+		if bytes.HasPrefix(raw, []byte("OM")) {
+			bom = 12
+		}
+		if len(raw) < bom+4 {
+			return nil, fmt.Errorf("olympus makernote too short length: %d, minimum: %d", len(raw), bom+4)
+		}
+		switch string(raw[bom : bom+2]) {
+		case "II":
+			mnHelper.Endian = binary.LittleEndian
+		case "MM":
+			mnHelper.Endian = binary.BigEndian
+		default:
+			return nil, errors.New("unsupported byte order")
+		}
+		mnHelper.TiffStart = mnStart
+		ifdStart = mnStart + bom + 4
+	case bytes.HasPrefix(raw, []byte("OLYMP\x00")):
+		ifdStart = mnStart + 8
+	default:
+		return nil, ErrNotMatched
+	}
+
+	parsed := &OlympusMakerNote{}
+
+	// Sub-IFDs stored inline contain their own IFD, so they are not claimed as flat values
+	_, err = mnHelper.WalkIFD("MakerNotes", ifdStart, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case OlympusCameraType:
+			parsed.CameraType = value.String()
+		case OlympusEquipment:
+			olympusSubIFD(mnHelper, "Equipment", entry, value, func(entry helpers.IFDEntry, value helpers.TagValue) {
+				decodeOlympusEquipment(entry, value, parsed)
+			})
+		case OlympusCameraSettings:
+			olympusSubIFD(mnHelper, "CameraSettings", entry, value, func(entry helpers.IFDEntry, value helpers.TagValue) {
+				if entry.Tag == OlympusPictureMode {
+					parsed.PictureMode = lookup(olympusPictureMode, value.Int(0))
+				}
+			})
+		}
+	}, OlympusEquipment, OlympusCameraSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// olympusSubIFD reads the entries of a sub-IFD, which newer cameras point to like any other IFD and
+// older ones store inline as an undefined value
+func olympusSubIFD(e helpers.ValueExtractor, name string, entry helpers.IFDEntry, value helpers.TagValue, decode func(helpers.IFDEntry, helpers.TagValue)) {
+	offset := value.Offset
+	if entry.DataType != helpers.TypeUndefined {
+		offset = e.TiffStart + int(value.Int(0))
+	}
+
+	if _, err := e.WalkIFD(name, offset, decode); err != nil {
+		e.Context.IFDError(name, offset, err)
+	}
+}
+
+func decodeOlympusEquipment(entry helpers.IFDEntry, value helpers.TagValue, parsed *OlympusMakerNote) {
+	switch entry.Tag {
+	case OlympusSerialNumber:
+		parsed.SerialNumber = strings.TrimSpace(value.String())
+	case OlympusInternalSerialNumber:
+		parsed.InternalSerialNumber = strings.TrimSpace(value.String())
+	case OlympusBodyFirmwareVersion:
+		parsed.BodyFirmwareVersion = olympusFirmwareVersion(uint32(value.Int(0)))
+	case OlympusLensType:
+		// Make, unknown, model and sub-model, which together identify the lens
+		if value.Len() >= 4 {
+			parsed.LensType = fmt.Sprintf("%x %02x %02x", value.Int(0), value.Int(2), value.Int(3))
+		}
+	case OlympusLensSerialNumber:
+		parsed.LensSerialNumber = strings.TrimSpace(value.String())
+	case OlympusLensModel:
+		parsed.LensModel = value.String()
+	case OlympusLensFirmwareVersion:
+		parsed.LensFirmwareVersion = olympusFirmwareVersion(uint32(value.Int(0)))
+	}
+}
+
+// olympusFirmwareVersion formats a firmware version, which reads as a version number when written
+// in hexadecimal with a point before the last three digits
+func olympusFirmwareVersion(raw uint32) string {
+	version := fmt.Sprintf("%04x", raw)
+	return version[:len(version)-3] + "." + version[len(version)-3:]
+}
@@ -0,0 +1,64 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// olympusMakerNote builds a little-endian Olympus MakerNote whose main IFD points at an Equipment
+// and a CameraSettings IFD following it. Offsets count from the start of the MakerNote.
+func olympusMakerNote(header string) []byte {
+	le := binary.LittleEndian
+
+	equipmentOffset := len(header) + 2 + 2*12 + 4
+	equipment := makerNoteIFD(le, equipmentOffset, []noteEntry{
+		{0x0101, 2, 12, []byte("BHP123456   ")},
+		{0x0104, 4, 1, le.AppendUint32(nil, 0x1200)},
+		{0x0201, 1, 6, []byte{0, 0, 0x21, 0x10, 0, 0}},
+		{0x0203, 2, 26, []byte("OM 12-40mm F2.8 PRO II\x00\x00\x00\x00")},
+	})
+	settingsOffset := equipmentOffset + len(equipment)
+	settings := makerNoteIFD(le, settingsOffset, []noteEntry{
+		{0x0520, 3, 2, []byte{2, 0, 0, 0}},
+	})
+
+	note := append([]byte(header), makerNoteIFD(le, len(header), []noteEntry{
+		{0x2010, 13, 1, le.AppendUint32(nil, uint32(equipmentOffset))},
+		{0x2020, 13, 1, le.AppendUint32(nil, uint32(settingsOffset))},
+	})...)
+	note = append(note, equipment...)
+	return append(note, settings...)
+}
+
+func TestOlympusParser(t *testing.T) {
+	for _, header := range []string{"OLYMPUS\x00II\x03\x00", "OM SYSTEM\x00\x00\x00II\x04\x00"} {
+		t.Run(header[:2], func(t *testing.T) {
+			e, entry := makerNoteExtractor(olympusMakerNote(header))
+			e.Context = &helpers.ParseContext{}
+			detection, parsed, err := DetectAndParse(e, entry, "OM Digital Solutions")
+			if err != nil || detection.Manufacturer != "Olympus" {
+				t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+			}
+			if len(e.Context.Diagnostics) != 0 {
+				t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+			}
+
+			olympus, ok := parsed.(*OlympusMakerNote)
+			if !ok {
+				t.Fatalf("parsed is %T, want *OlympusMakerNote", parsed)
+			}
+			want := OlympusMakerNote{
+				SerialNumber:        "BHP123456",
+				BodyFirmwareVersion: "1.200",
+				LensType:            "0 21 10",
+				LensModel:           "OM 12-40mm F2.8 PRO II",
+				PictureMode:         "Natural",
+			}
+			if *olympus != want {
+				t.Errorf("parsed = %+v, want %+v", *olympus, want)
+			}
+		})
+	}
+}
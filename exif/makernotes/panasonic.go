@@ -0,0 +1,122 @@
+package makernotes
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Panasonic MakerNote Tags
+const (
+	PanasonicImageQuality         helpers.Tag = 0x0001
+	PanasonicFirmwareVersion      helpers.Tag = 0x0002
+	PanasonicInternalSerialNumber helpers.Tag = 0x0025
+	PanasonicTimeSincePowerOn     helpers.Tag = 0x0029
+	PanasonicSequenceNumber       helpers.Tag = 0x002b
+	PanasonicLensType             helpers.Tag = 0x0051
+	PanasonicLensSerialNumber     helpers.Tag = 0x0052
+	PanasonicPhotoStyle           helpers.Tag = 0x0089
+)
+
+var panasonicImageQuality = map[int64]string{
+	1: "TIFF", 2: "High", 3: "Normal", 6: "Very High", 7: "RAW", 9: "Motion Picture", 11: "Full HD Movie",
+	12: "4k Movie",
+}
+
+var panasonicPhotoStyle = map[int64]string{
+	0: "Auto", 1: "Standard or Custom", 2: "Vivid", 3: "Natural", 4: "Monochrome", 5: "Scenery",
+	6: "Portrait", 8: "Cinelike D", 9: "Cinelike V", 11: "L. Monochrome", 12: "Like709",
+	15: "L. Monochrome D", 17: "V-Log", 18: "Cinelike D2",
+}
+
+// PanasonicMakerNote The values decoded from a Panasonic MakerNote
+type PanasonicMakerNote struct {
+	ImageQuality         string `json:"imageQuality,omitempty"`
+	FirmwareVersion      string `json:"firmwareVersion,omitempty"`
+	InternalSerialNumber string `json:"internalSerialNumber,omitempty"`
+	// TimeSincePowerOn is in seconds
+	TimeSincePowerOn float64 `json:"timeSincePowerOn,omitempty"`
+	// SequenceNumber is the position within a burst, and 0 for single shots
+	SequenceNumber   uint32 `json:"sequenceNumber,omitempty"`
+	LensType         string `json:"lensType,omitempty"`
+	LensSerialNumber string `json:"lensSerialNumber,omitempty"`
+	PhotoStyle       string `json:"photoStyle,omitempty"`
+}
+
+func (p *PanasonicMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(p)
+}
+
+type PanasonicParser struct{}
+
+func (p *PanasonicParser) Manufacturer() string {
+	return "Panasonic"
+}
+
+// Parse decodes a Panasonic MakerNote, an IFD after a 12 byte "Panasonic" header whose value offsets
+// are relative to the EXIF TIFF header. Its only image counters are the sequence number within a
+// burst and the time since power on: Panasonic keeps the total shutter count out of the MakerNote.
+func (p *PanasonicParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	raw := value.Bytes()
+	mnStart := value.Offset
+
+	if !bytes.HasPrefix(raw, []byte("Panasonic\x00\x00\x00")) {
+		return nil, ErrNotMatched
+	}
+
+	mnHelper := helpers.ValueExtractor{
+		Source:    e.Source,
+		TiffStart: e.TiffStart,
+		Endian:    e.Endian,
+		Context:   e.Context,
+	}
+	ifdStart := mnStart + 12
+
+	parsed := &PanasonicMakerNote{}
+
+	_, err = mnHelper.WalkIFD("MakerNotes", ifdStart, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case PanasonicImageQuality:
+			parsed.ImageQuality = lookup(panasonicImageQuality, value.Int(0))
+		case PanasonicFirmwareVersion:
+			parsed.FirmwareVersion = panasonicFirmwareVersion(value.Bytes())
+		case PanasonicInternalSerialNumber:
+			parsed.InternalSerialNumber = value.String()
+		case PanasonicTimeSincePowerOn:
+			// Hundredths of a second
+			parsed.TimeSincePowerOn = float64(uint32(value.Int(0))) / 100
+		case PanasonicSequenceNumber:
+			parsed.SequenceNumber = uint32(value.Int(0))
+		case PanasonicLensType:
+			parsed.LensType = strings.TrimSpace(value.String())
+		case PanasonicLensSerialNumber:
+			parsed.LensSerialNumber = strings.TrimSpace(value.String())
+		case PanasonicPhotoStyle:
+			parsed.PhotoStyle = lookup(panasonicPhotoStyle, value.Int(0))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// panasonicFirmwareVersion formats the four version bytes, which some models store as ASCII digits
+// and others as numbers
+func panasonicFirmwareVersion(raw []byte) string {
+	if isPrintable(raw) {
+		return strings.TrimRight(string(raw), "\x00")
+	}
+	parts := make([]string, len(raw))
+	for i, b := range raw {
+		parts[i] = fmt.Sprint(b)
+	}
+	return strings.Join(parts, ".")
+}
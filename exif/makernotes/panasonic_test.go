@@ -0,0 +1,50 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestPanasonicParser(t *testing.T) {
+	// The IFD follows the 12 byte header at offset 24, and offsets count from the EXIF TIFF header
+	note := append([]byte("Panasonic\x00\x00\x00"), makerNoteIFD(binary.BigEndian, 24, []noteEntry{
+		{0x0001, 3, 1, shorts(2)},
+		{0x0002, 7, 4, []byte{0, 1, 0, 7}},
+		{0x0025, 7, 16, []byte("F541208160140\x00\x00\x00")},
+		{0x0029, 4, 1, []byte{0, 0, 0x30, 0x39}},
+		{0x002b, 4, 1, []byte{0, 0, 0, 3}},
+		{0x0051, 2, 20, []byte("LUMIX G 20/F1.7 II\x00\x00")},
+		{0x0052, 2, 12, []byte("XA1234567   ")},
+		{0x0089, 3, 1, shorts(8)},
+	})...)
+
+	e, entry := makerNoteExtractor(note)
+	e.Context = &helpers.ParseContext{}
+	detection, parsed, err := DetectAndParse(e, entry, "Panasonic")
+	if err != nil || detection.Manufacturer != "Panasonic" {
+		t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+	}
+	if len(e.Context.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", e.Context.Diagnostics)
+	}
+
+	panasonic, ok := parsed.(*PanasonicMakerNote)
+	if !ok {
+		t.Fatalf("parsed is %T, want *PanasonicMakerNote", parsed)
+	}
+	want := PanasonicMakerNote{
+		ImageQuality:         "High",
+		FirmwareVersion:      "0.1.0.7",
+		InternalSerialNumber: "F541208160140",
+		TimeSincePowerOn:     123.45,
+		SequenceNumber:       3,
+		LensType:             "LUMIX G 20/F1.7 II",
+		LensSerialNumber:     "XA1234567",
+		PhotoStyle:           "Cinelike D",
+	}
+	if *panasonic != want {
+		t.Errorf("parsed = %+v, want %+v", *panasonic, want)
+	}
+}
@@ -1,27 +1,170 @@
 package makernotes
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/ZanyLeonic/exif-reader/exif/helpers"
 )
 
+// ErrNotMatched is returned by a parser for a MakerNote in another manufacturer's format, so that
+// the registry moves on to the next candidate
+var ErrNotMatched = errors.New("MakerNote is not in this format")
+
+// Parser decodes one manufacturer's MakerNote. cameraMake is the Make tag from IFD0, which identifies
+// formats without a signature of their own.
 type Parser interface {
-	Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry) (*map[string]interface{}, error)
+	Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error)
 	Manufacturer() string
 }
 
-func DetectAndParse(e *helpers.ValueExtractor, entry helpers.IFDEntry) (string, *map[string]interface{}, error) {
-	parsers := []Parser{
-		&AppleParser{},
+// Match describes the MakerNotes a parser handles
+type Match struct {
+	// Signatures are prefixes of the MakerNote that identify its format
+	Signatures []string
+	// Makes are prefixes of the Make tag, compared ignoring case, for formats without a signature
+	Makes []string
+}
+
+// Detection reports which parser decoded a MakerNote and why it was chosen
+type Detection struct {
+	Manufacturer string
+	// Reason is the signature or Make value that selected the parser
+	Reason string
+}
+
+type registration struct {
+	parser Parser
+	match  Match
+}
+
+// Registry Parsers indexed by the MakerNote signatures and Make values they handle
+type Registry struct {
+	mu      sync.RWMutex
+	entries []registration
+}
+
+// NewRegistry returns a registry holding the built-in parsers
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.Register(&AppleParser{}, Match{Signatures: []string{"Apple iOS\x00"}})
+	r.Register(&CanonParser{}, Match{Makes: []string{"Canon"}})
+	r.Register(&NikonParser{}, Match{Signatures: []string{"Nikon\x00\x01", "Nikon\x00\x02"}, Makes: []string{"NIKON"}})
+	r.Register(&SonyParser{}, Match{Signatures: sonyHeaders, Makes: []string{"SONY"}})
+	r.Register(&FujifilmParser{}, Match{Signatures: []string{"FUJIFILM"}})
+	r.Register(&OlympusParser{}, Match{Signatures: []string{"OLYMPUS\x00", "OM SYSTEM\x00", "OLYMP\x00"}})
+	r.Register(&PanasonicParser{}, Match{Signatures: []string{"Panasonic\x00\x00\x00"}})
+	r.Register(&DJIParser{}, Match{Makes: []string{"DJI"}})
+	return r
+}
+
+// Register adds a parser. Parsers registered later are tried first, so a built-in parser can be
+// replaced by registering another for the same signatures.
+func (r *Registry) Register(parser Parser, match Match) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append([]registration{{parser, match}}, r.entries...)
+}
+
+// candidate is a parser selected for a MakerNote, with the reason it was selected
+type candidate struct {
+	parser Parser
+	reason string
+}
+
+// candidates lists the parsers whose signature starts the MakerNote, followed by those whose Make
+// matches, as a signature is the stronger evidence
+func (r *Registry) candidates(raw []byte, cameraMake string) []candidate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var bySignature, byMake []candidate
+	for _, reg := range r.entries {
+		if i := slices.IndexFunc(reg.match.Signatures, func(sig string) bool { return bytes.HasPrefix(raw, []byte(sig)) }); i >= 0 {
+			bySignature = append(bySignature, candidate{reg.parser, fmt.Sprintf("signature %q", reg.match.Signatures[i])})
+			continue
+		}
+		if i := slices.IndexFunc(reg.match.Makes, func(prefix string) bool { return hasPrefixFold(cameraMake, prefix) }); i >= 0 {
+			byMake = append(byMake, candidate{reg.parser, fmt.Sprintf("make %q", cameraMake)})
+		}
+	}
+	return append(bySignature, byMake...)
+}
+
+// DetectAndParse picks the parsers whose signature or Make matches the MakerNote and returns the
+// result of the first that accepts it. A parser returning ErrNotMatched is skipped, and any other
+// error is returned with the parser that produced it.
+func (r *Registry) DetectAndParse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (Detection, helpers.MakerNoteFields, error) {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return Detection{Manufacturer: "Unknown"}, nil, err
 	}
 
-	for _, parser := range parsers {
-		if parsed, err := parser.Parse(e, entry); err == nil && parsed != nil {
-			return parser.Manufacturer(), parsed, nil
-		} else if err != nil {
-			return parser.Manufacturer(), nil, err
+	for _, c := range r.candidates(value.Bytes(), cameraMake) {
+		detection := Detection{Manufacturer: c.parser.Manufacturer(), Reason: c.reason}
+		parsed, err := c.parser.Parse(e, entry, cameraMake)
+		if errors.Is(err, ErrNotMatched) {
+			e.Context.Log().Debug("MakerNote parser declined", "manufacturer", detection.Manufacturer, "reason", detection.Reason)
+			continue
+		}
+		if err != nil {
+			return detection, nil, err
+		}
+		if parsed != nil {
+			e.Context.Log().Debug("MakerNote parser selected", "manufacturer", detection.Manufacturer, "reason", detection.Reason)
+			return detection, parsed, nil
 		}
 	}
-	return "Unknown", nil, errors.New("cannot parse makernote, corrupted or unsupported")
+
+	prefix := value.Bytes()[:min(len(value.Bytes()), 12)]
+	return Detection{Manufacturer: "Unknown"}, nil, fmt.Errorf("no parser for MakerNote starting %q from make %q", prefix, cameraMake)
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds a parser to the registry used by DetectAndParse
+func Register(parser Parser, match Match) {
+	defaultRegistry.Register(parser, match)
+}
+
+// DetectAndParse decodes a MakerNote with the default registry's parsers
+func DetectAndParse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (Detection, helpers.MakerNoteFields, error) {
+	return defaultRegistry.DetectAndParse(e, entry, cameraMake)
+}
+
+// hasPrefixFold reports whether s begins with prefix, ignoring case
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// lensSpec formats the minimum and maximum focal lengths and the widest apertures at each, as
+// stored by several manufacturers
+func lensSpec(values []float64) string {
+	focal := fmt.Sprintf("%gmm", values[0])
+	if values[1] != values[0] {
+		focal = fmt.Sprintf("%g-%gmm", values[0], values[1])
+	}
+	aperture := fmt.Sprintf("f/%g", values[2])
+	if values[3] != values[2] {
+		aperture = fmt.Sprintf("f/%g-%g", values[2], values[3])
+	}
+	return focal + " " + aperture
+}
+
+// lookup names a value, falling back to the number for values missing from the table
+func lookup(values map[int64]string, raw int64) string {
+	return lookupFormat(values, raw, "Unknown (%d)")
+}
+
+// lookupFormat is lookup with the fallback for missing values formatted by format, for tables whose
+// values are better known in hex
+func lookupFormat(values map[int64]string, raw int64, format string) string {
+	if name, ok := values[raw]; ok {
+		return name
+	}
+	return fmt.Sprintf(format, raw)
 }
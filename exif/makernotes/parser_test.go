@@ -0,0 +1,133 @@
+package makernotes
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// testParser accepts MakerNotes starting with its prefix and declines everything else
+type testParser struct {
+	name   string
+	prefix string
+	calls  int
+}
+
+func (p *testParser) Manufacturer() string {
+	return p.name
+}
+
+func (p *testParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	p.calls++
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(value.Bytes(), []byte(p.prefix)) {
+		return nil, ErrNotMatched
+	}
+	return helpers.GenericMakerNote{"Parser": p.name}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	acme := &testParser{name: "Acme", prefix: "ACME\x00"}
+	acmeV2 := &testParser{name: "Acme v2", prefix: "ACME\x00\x02"}
+	widget := &testParser{name: "Widget", prefix: "WIDGET"}
+	unused := &testParser{name: "Unused", prefix: "UNUSED"}
+
+	r := &Registry{}
+	r.Register(acme, Match{Signatures: []string{"ACME\x00"}})
+	r.Register(acmeV2, Match{Signatures: []string{"ACME\x00"}})
+	r.Register(widget, Match{Makes: []string{"Widget"}})
+	r.Register(unused, Match{Signatures: []string{"UNUSED"}, Makes: []string{"Unused"}})
+
+	tests := []struct {
+		name       string
+		note       string
+		cameraMake string
+		want       Detection
+	}{
+		{"signature", "ACME\x00\x01", "", Detection{"Acme", `signature "ACME\x00"`}},
+		// The later registration is tried first, and declines notes that are not its version
+		{"later registration first", "ACME\x00\x02", "", Detection{"Acme v2", `signature "ACME\x00"`}},
+		{"make ignores case", "WIDGET", "WIDGET CORP", Detection{"Widget", `make "WIDGET CORP"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, entry := makerNoteExtractor([]byte(tt.note))
+			detection, parsed, err := r.DetectAndParse(e, entry, tt.cameraMake)
+			if err != nil {
+				t.Fatalf("DetectAndParse() error = %v", err)
+			}
+			if detection != tt.want {
+				t.Errorf("DetectAndParse() = %+v, want %+v", detection, tt.want)
+			}
+			if parsed.Fields()["Parser"] != tt.want.Manufacturer {
+				t.Errorf("parsed by %v, want %s", parsed.Fields()["Parser"], tt.want.Manufacturer)
+			}
+		})
+	}
+	if unused.calls != 0 {
+		t.Errorf("parser without a matching signature or make was called %d times", unused.calls)
+	}
+
+	// A matched make whose parser declines the note falls through to Unknown
+	e, entry := makerNoteExtractor([]byte("GADGET"))
+	detection, parsed, err := r.DetectAndParse(e, entry, "Widget")
+	if err == nil || parsed != nil || detection.Manufacturer != "Unknown" {
+		t.Errorf("DetectAndParse() = %+v, %v, %v, want Unknown and an error", detection, parsed, err)
+	}
+}
+
+func TestRegistryReturnsParserError(t *testing.T) {
+	failing := errors.New("corrupt")
+	r := &Registry{}
+	r.Register(parserFunc(func() error { return failing }), Match{Makes: []string{"Acme"}})
+
+	e, entry := makerNoteExtractor(make([]byte, 8))
+	detection, _, err := r.DetectAndParse(e, entry, "Acme")
+	if !errors.Is(err, failing) || detection.Reason != `make "Acme"` {
+		t.Errorf("DetectAndParse() = %+v, %v, want the parser's error", detection, err)
+	}
+}
+
+func TestDefaultRegistryReason(t *testing.T) {
+	e, entry := makerNoteExtractor(nikonMakerNote([]noteEntry{{0x0004, 2, 4, []byte("RAW\x00")}}))
+	detection, _, err := DetectAndParse(e, entry, "")
+	if err != nil {
+		t.Fatalf("DetectAndParse() error = %v", err)
+	}
+	if want := (Detection{"Nikon", `signature "Nikon\x00\x02"`}); detection != want {
+		t.Errorf("DetectAndParse() = %+v, want %+v", detection, want)
+	}
+
+	// Canon notes have no signature, so only the make selects the parser
+	note := canonMakerNote([]noteEntry{{0x0006, 2, 4, []byte("EOS\x00")}})
+	e, entry = makerNoteExtractor(note)
+	if detection, _, err := DetectAndParse(e, entry, "Unknown Corp"); err == nil {
+		t.Errorf("DetectAndParse() = %+v, want an error for an unknown Make", detection)
+	}
+	e, entry = makerNoteExtractor(note)
+	detection, _, err = DetectAndParse(e, entry, "Canon")
+	if want := (Detection{"Canon", `make "Canon"`}); err != nil || detection != want {
+		t.Errorf("DetectAndParse() = %+v, %v, want %+v", detection, err, want)
+	}
+
+	// The make is matched without regard to case, by the registry and the parser alike
+	e, entry = makerNoteExtractor(note)
+	if _, parsed, err := DetectAndParse(e, entry, "CANON"); err != nil || parsed.(*CanonMakerNote).ImageType != "EOS" {
+		t.Errorf("DetectAndParse() = %+v, %v for an upper case Make", parsed, err)
+	}
+}
+
+type parserFunc func() error
+
+func (f parserFunc) Manufacturer() string {
+	return "Func"
+}
+
+func (f parserFunc) Parse(*helpers.ValueExtractor, helpers.IFDEntry, string) (helpers.MakerNoteFields, error) {
+	return nil, f()
+}
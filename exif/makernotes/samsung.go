@@ -0,0 +1,168 @@
+package makernotes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Samsung trailers end the file with the SEFH directory, its length and "SEFT". Each directory entry
+// locates a data block by counting back from the start of SEFH, and every block repeats the first
+// four bytes of its entry, then holds the length of its name, the name and the data.
+const (
+	samsungTrailerMagic   = "SEFT"
+	samsungDirectoryMagic = "SEFH"
+	samsungHeaderSize     = 12
+	samsungEntrySize      = 12
+	samsungBlockHeader    = 8
+	// maxSamsungText is the longest block treated as a text value, and how much of each block is
+	// read to recognise embedded files
+	maxSamsungText = 256
+)
+
+// ParseSamsungTrailer reads the Samsung SEFH directory at the end of the file and the header of every
+// block it lists. It returns nil without an error when the file has no trailer.
+func ParseSamsungTrailer(src *helpers.Source, ctx *helpers.ParseContext) (*helpers.SamsungTrailerData, error) {
+	footer, err := src.Slice(src.Size()-8, 8)
+	if err != nil || string(footer[4:8]) != samsungTrailerMagic {
+		return nil, nil
+	}
+
+	dirLength := int(binary.LittleEndian.Uint32(footer[0:4]))
+	dirStart := src.Size() - 8 - dirLength
+	if dirLength < samsungHeaderSize || dirStart < 0 {
+		return nil, fmt.Errorf("SEFH directory length %d out of range", dirLength)
+	}
+
+	dir, err := src.Slice(dirStart, dirLength)
+	if err != nil {
+		return nil, err
+	}
+	if string(dir[0:4]) != samsungDirectoryMagic {
+		return nil, errors.New("SEFT footer does not point at a SEFH directory")
+	}
+
+	trailer := &helpers.SamsungTrailerData{
+		Offset:  dirStart,
+		Version: int(binary.LittleEndian.Uint32(dir[4:8])),
+	}
+
+	count := int(binary.LittleEndian.Uint32(dir[8:12]))
+	if fit := (dirLength - samsungHeaderSize) / samsungEntrySize; count > fit {
+		ctx.Warn(helpers.DiagTruncated, "SamsungTrailer", dirStart, "SEFH directory declares more entries than it holds",
+			"count", count, "fit", fit)
+		count = fit
+	}
+
+	for i := 0; i < count; i++ {
+		raw := dir[samsungHeaderSize+i*samsungEntrySize : samsungHeaderSize+(i+1)*samsungEntrySize]
+		blockStart := dirStart - int(binary.LittleEndian.Uint32(raw[4:8]))
+		blockLength := int(binary.LittleEndian.Uint32(raw[8:12]))
+
+		entry, err := readSamsungBlock(src, raw[0:4], blockStart, blockLength, dirStart)
+		if err != nil {
+			ctx.Warn(helpers.DiagOutOfRange, "SamsungTrailer", blockStart, "cannot read Samsung trailer block: "+err.Error(),
+				"type", fmt.Sprintf("%#04x", binary.LittleEndian.Uint16(raw[2:4])))
+			continue
+		}
+		trailer.Entries = append(trailer.Entries, entry)
+
+		switch entry.Name {
+		case "Image_UTC_Data":
+			// Milliseconds since the Unix epoch as decimal text
+			ms, err := strconv.ParseInt(entry.Value, 10, 64)
+			if err != nil {
+				ctx.Warn(helpers.DiagInvalidDate, "SamsungTrailer", entry.Offset, "invalid Image_UTC_Data", "value", entry.Value)
+				break
+			}
+			trailer.CaptureTime = time.UnixMilli(ms).UTC()
+		case "MCC_Data":
+			trailer.MCC = entry.Value
+		case "Camera_Capture_Mode_Info":
+			trailer.CameraCaptureModeInfo = entry.Value
+		case "Dual_Shot_Info":
+			trailer.DualShotInfo = entry.Value
+		}
+	}
+
+	return trailer, nil
+}
+
+// readSamsungBlock reads the name of the block at start, checks it against its directory entry and
+// identifies its data, which must end before the directory
+func readSamsungBlock(src *helpers.Source, entryHeader []byte, start, length, dirStart int) (helpers.SamsungTrailerEntry, error) {
+	if start < 0 || length < samsungBlockHeader || length > dirStart-start {
+		return helpers.SamsungTrailerEntry{}, fmt.Errorf("block at offset %d, length %d lies outside the trailer", start, length)
+	}
+
+	header, err := src.Slice(start, samsungBlockHeader)
+	if err != nil {
+		return helpers.SamsungTrailerEntry{}, err
+	}
+	if !bytes.Equal(header[0:4], entryHeader) {
+		return helpers.SamsungTrailerEntry{}, fmt.Errorf("block header %x does not match its directory entry %x", header[0:4], entryHeader)
+	}
+
+	nameLength := int(binary.LittleEndian.Uint32(header[4:8]))
+	if nameLength > length-samsungBlockHeader {
+		return helpers.SamsungTrailerEntry{}, fmt.Errorf("block name length %d overruns the block", nameLength)
+	}
+	name, err := src.Slice(start+samsungBlockHeader, nameLength)
+	if err != nil {
+		return helpers.SamsungTrailerEntry{}, err
+	}
+
+	entry := helpers.SamsungTrailerEntry{
+		Type:   binary.LittleEndian.Uint16(header[2:4]),
+		Name:   string(name),
+		Offset: start + samsungBlockHeader + nameLength,
+		Length: length - samsungBlockHeader - nameLength,
+	}
+
+	head, err := src.Slice(entry.Offset, min(entry.Length, maxSamsungText))
+	if err != nil {
+		return helpers.SamsungTrailerEntry{}, err
+	}
+	entry.MediaType = samsungMediaType(entry.Name, head)
+	if entry.MediaType == "" && entry.Length <= maxSamsungText && isPrintable(head) {
+		entry.Value = strings.TrimRight(string(head), "\x00")
+	}
+
+	return entry, nil
+}
+
+// samsungMediaType recognises embedded files by their signature, falling back to the block name for
+// depth maps, which are stored as raw samples
+func samsungMediaType(name string, head []byte) string {
+	switch {
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return "video/mp4"
+	case bytes.HasPrefix(head, []byte{0xff, 0xd8, 0xff}):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case strings.Contains(name, "DepthMap"):
+		return "application/octet-stream"
+	}
+	return ""
+}
+
+// isPrintable reports whether data is ASCII text, allowing NUL padding at the end
+func isPrintable(data []byte) bool {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return false
+	}
+	for _, b := range data {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package makernotes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestParseSamsungTrailer(t *testing.T) {
+	image := []byte{0xff, 0xd8, 0xff, 0xd9}
+	video := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	data := samsungTrailer(image,
+		samsungBlock{0x0a01, "Image_UTC_Data", []byte("1727791132085")},
+		samsungBlock{0x0aa1, "MCC_Data", []byte("234")},
+		samsungBlock{0x0ab3, "Camera_Capture_Mode_Info", []byte("1")},
+		samsungBlock{0x0a30, "MotionPhoto_Data", video},
+	)
+
+	ctx := &helpers.ParseContext{}
+	trailer, err := ParseSamsungTrailer(helpers.NewBytesSource(data), ctx)
+	if err != nil || trailer == nil {
+		t.Fatalf("ParseSamsungTrailer() = %v, %v", trailer, err)
+	}
+	if len(ctx.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", ctx.Diagnostics)
+	}
+
+	if want := time.UnixMilli(1727791132085).UTC(); !trailer.CaptureTime.Equal(want) {
+		t.Errorf("CaptureTime = %v, want %v", trailer.CaptureTime, want)
+	}
+	if trailer.MCC != "234" || trailer.CameraCaptureModeInfo != "1" || trailer.Version != 107 {
+		t.Errorf("MCC %q, CameraCaptureModeInfo %q, Version %d", trailer.MCC, trailer.CameraCaptureModeInfo, trailer.Version)
+	}
+	if len(trailer.Entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(trailer.Entries))
+	}
+
+	motion := trailer.Entries[3]
+	if motion.MediaType != "video/mp4" || motion.Length != len(video) || string(data[motion.Offset:motion.Offset+motion.Length]) != string(video) {
+		t.Errorf("motion photo entry %+v does not locate the video", motion)
+	}
+
+	if trailer, err := ParseSamsungTrailer(helpers.NewBytesSource(image), ctx); trailer != nil || err != nil {
+		t.Errorf("file without a trailer: %v, %v", trailer, err)
+	}
+}
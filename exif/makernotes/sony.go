@@ -0,0 +1,226 @@
+package makernotes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// Sony MakerNote Tags
+const (
+	SonyQuality       helpers.Tag = 0x0102
+	SonyTag9050       helpers.Tag = 0x9050
+	SonyTag9400       helpers.Tag = 0x9400
+	SonyCreativeStyle helpers.Tag = 0xb020
+	SonyLensType      helpers.Tag = 0xb027
+	SonyLensSpec      helpers.Tag = 0xb02a
+)
+
+// sonyHeaders are the prefixes of Sony MakerNotes that have a header. Notes from most interchangeable
+// lens cameras are a bare IFD instead.
+var sonyHeaders = []string{"SONY DSC \x00\x00\x00", "SONY CAM \x00\x00\x00"}
+
+var sonyQuality = map[int64]string{
+	0: "RAW", 1: "Super Fine", 2: "Fine", 3: "Standard", 4: "Economy", 5: "Extra Fine", 6: "RAW + JPEG/HEIF",
+	7: "Compressed RAW", 8: "Compressed RAW + JPEG", 9: "Light", 0xffffffff: "n/a",
+}
+
+// sonyDecipher inverts the substitution cipher of the 0x94xx and 0x9050 tags, which replaces each
+// byte below 249 with its cube modulo 249
+var sonyDecipher = func() [256]byte {
+	var table [256]byte
+	for b := 0; b < 256; b++ {
+		if b < 249 {
+			table[b*b*b%249] = byte(b)
+		} else {
+			table[b] = byte(b)
+		}
+	}
+	return table
+}()
+
+// SonyMakerNote The values decoded from a Sony MakerNote, including its enciphered 0x9050 and 0x9400
+// blocks
+type SonyMakerNote struct {
+	Quality       string `json:"quality,omitempty"`
+	CreativeStyle string `json:"creativeStyle,omitempty"`
+	// LensType is the A-mount lens number, or a description shared by E-mount lenses and adapters
+	LensType             string `json:"lensType,omitempty"`
+	LensSpec             string `json:"lensSpec,omitempty"`
+	LensSpecFeatures     string `json:"lensSpecFeatures,omitempty"`
+	ShutterCount         uint32 `json:"shutterCount,omitempty"`
+	InternalSerialNumber string `json:"internalSerialNumber,omitempty"`
+	// SequenceImageNumber counts from 1 within a burst
+	SequenceImageNumber    uint32 `json:"sequenceImageNumber,omitempty"`
+	ShotNumberSincePowerUp uint32 `json:"shotNumberSincePowerUp,omitempty"`
+}
+
+func (s *SonyMakerNote) Fields() map[string]interface{} {
+	return helpers.StructFields(s)
+}
+
+type SonyParser struct{}
+
+func (p *SonyParser) Manufacturer() string {
+	return "Sony"
+}
+
+// Parse decodes a Sony MakerNote. Whether or not it has a header, its value offsets are relative to
+// the EXIF TIFF header.
+func (p *SonyParser) Parse(e *helpers.ValueExtractor, entry helpers.IFDEntry, cameraMake string) (helpers.MakerNoteFields, error) {
+	value, err := e.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	raw := value.Bytes()
+	mnStart := value.Offset
+
+	ifdStart := -1
+	for _, header := range sonyHeaders {
+		if bytes.HasPrefix(raw, []byte(header)) {
+			ifdStart = mnStart + len(header)
+			break
+		}
+	}
+	if ifdStart < 0 {
+		if !strings.HasPrefix(strings.ToUpper(cameraMake), "SONY") {
+			return nil, ErrNotMatched
+		}
+		ifdStart = mnStart
+	}
+
+	mnHelper := helpers.ValueExtractor{
+		Source:    e.Source,
+		TiffStart: e.TiffStart,
+		Endian:    e.Endian,
+		Context:   e.Context,
+	}
+
+	parsed := &SonyMakerNote{}
+	var tag9050, tag9400 []byte
+
+	_, err = mnHelper.WalkIFD("MakerNotes", ifdStart, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case SonyQuality:
+			parsed.Quality = lookup(sonyQuality, value.Int(0))
+		case SonyCreativeStyle:
+			parsed.CreativeStyle = value.String()
+		case SonyLensType:
+			// A-mount lenses are identified here, while E-mount lenses and adapters share one value
+			lensType := uint32(value.Int(0))
+			if lensType == 0xffff {
+				parsed.LensType = "E-Mount, T-Mount, Other Lens or no lens"
+			} else {
+				parsed.LensType = strconv.FormatUint(uint64(lensType), 10)
+			}
+		case SonyLensSpec:
+			decodeSonyLensSpec(value.Bytes(), parsed)
+		case SonyTag9050:
+			tag9050 = DecipherSonyBytes(value.Bytes())
+		case SonyTag9400:
+			tag9400 = DecipherSonyBytes(value.Bytes())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Which layout 0x9050 has depends on the model. Models whose 0x9400 is version 0x23 or later, or
+	// which replaced it with 0x9416, moved the shutter count and serial number.
+	layout9050b := len(tag9400) == 0 || tag9400[0] >= 0x23
+	if len(tag9400) > 0 {
+		decodeSonyTag9400(tag9400, mnHelper.Endian, parsed)
+	}
+	if len(tag9050) > 0 {
+		decodeSonyTag9050(tag9050, layout9050b, mnHelper.Endian, parsed)
+	}
+
+	return parsed, nil
+}
+
+// DecipherSonyBytes reverses the substitution cipher Sony applies to the 0x94xx and 0x9050 tags
+// (ported from the exiftool project). The data is not modified.
+func DecipherSonyBytes(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = sonyDecipher[b]
+	}
+	return out
+}
+
+// decodeSonyTag9050 reads the shutter count, which is 24 bits in a 32-bit field, and the internal
+// serial number from the deciphered 0x9050 block
+func decodeSonyTag9050(data []byte, layoutB bool, endian binary.ByteOrder, parsed *SonyMakerNote) {
+	shutterCount, serial, serialLength := 0x32, 0xf0, 5
+	if layoutB {
+		shutterCount, serial, serialLength = 0x3a, 0x88, 6
+	}
+
+	if len(data) >= shutterCount+4 {
+		parsed.ShutterCount = endian.Uint32(data[shutterCount:]) & 0x00ffffff
+	}
+	if len(data) >= serial+serialLength {
+		parsed.InternalSerialNumber = hex.EncodeToString(data[serial : serial+serialLength])
+	}
+}
+
+// decodeSonyTag9400 reads the image counters from the deciphered 0x9400 block, whose first byte
+// selects the layout
+func decodeSonyTag9400(data []byte, endian binary.ByteOrder, parsed *SonyMakerNote) {
+	var sequenceImage, sinceStart int
+	switch data[0] {
+	case 0x07, 0x09, 0x0a, 0x0c:
+		sequenceImage, sinceStart = 0x08, 0x1a
+	case 0x23, 0x24, 0x26, 0x28, 0x31, 0x32, 0x33:
+		sequenceImage, sinceStart = 0x12, 0x0a
+	default:
+		return
+	}
+
+	// Sequence numbers count from zero
+	if len(data) >= sequenceImage+4 {
+		parsed.SequenceImageNumber = endian.Uint32(data[sequenceImage:]) + 1
+	}
+	if len(data) >= sinceStart+4 {
+		parsed.ShotNumberSincePowerUp = endian.Uint32(data[sinceStart:])
+	}
+}
+
+// decodeSonyLensSpec decodes the LensSpec tag, where the focal lengths and apertures are stored as
+// binary-coded decimal between two bytes of feature flags
+func decodeSonyLensSpec(data []byte, parsed *SonyMakerNote) {
+	if len(data) < 8 {
+		return
+	}
+	bcd := func(b []byte) int {
+		n, _ := strconv.Atoi(hex.EncodeToString(b))
+		return n
+	}
+
+	minFocal, maxFocal := bcd(data[1:3]), bcd(data[3:5])
+	minAperture, maxAperture := float64(bcd(data[5:6]))/10, float64(bcd(data[6:7]))/10
+	if minFocal == 0 {
+		return
+	}
+
+	spec := fmt.Sprintf("%dmm", minFocal)
+	if maxFocal != minFocal && maxFocal != 0 {
+		spec = fmt.Sprintf("%d-%dmm", minFocal, maxFocal)
+	}
+	if minAperture != 0 {
+		spec += fmt.Sprintf(" F%g", minAperture)
+		if maxAperture != minAperture && maxAperture != 0 {
+			spec += fmt.Sprintf("-%g", maxAperture)
+		}
+	}
+	parsed.LensSpec = spec
+
+	if flags := uint16(data[0])<<8 | uint16(data[7]); flags != 0 {
+		parsed.LensSpecFeatures = fmt.Sprintf("0x%04x", flags)
+	}
+}
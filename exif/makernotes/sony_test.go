@@ -0,0 +1,92 @@
+package makernotes
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// sonyEncipher applies Sony's substitution cipher, cubing each byte below 249 modulo 249
+func sonyEncipher(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b
+		if b < 249 {
+			out[i] = byte(int(b) * int(b) * int(b) % 249)
+		}
+	}
+	return out
+}
+
+func TestDecipherSonyBytes(t *testing.T) {
+	plain := make([]byte, 256)
+	for i := range plain {
+		plain[i] = byte(i)
+	}
+	if got := DecipherSonyBytes(sonyEncipher(plain)); string(got) != string(plain) {
+		t.Errorf("round trip = %x", got)
+	}
+}
+
+func TestSonyParser(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		version9400  byte
+		shutterCount int
+		serial       int
+		serialHex    string
+	}{
+		{"SLT and early NEX", 0x0c, 0x32, 0xf0, "0102030405"},
+		{"later ILCE", 0x23, 0x3a, 0x88, "010203040506"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tag9050 := make([]byte, 0x100)
+			binary.BigEndian.PutUint32(tag9050[test.shutterCount:], 0x7f001234)
+			copy(tag9050[test.serial:], []byte{1, 2, 3, 4, 5, 6})
+
+			tag9400 := make([]byte, 0x40)
+			tag9400[0] = test.version9400
+			sequence, sinceStart := 0x08, 0x1a
+			if test.version9400 >= 0x23 {
+				sequence, sinceStart = 0x12, 0x0a
+			}
+			binary.BigEndian.PutUint32(tag9400[sequence:], 2)
+			binary.BigEndian.PutUint32(tag9400[sinceStart:], 57)
+
+			note := append([]byte("SONY DSC \x00\x00\x00"), makerNoteIFD(binary.BigEndian, 24, []noteEntry{
+				{0x0102, 4, 1, []byte{0, 0, 0, 1}},
+				{0x9050, 7, uint32(len(tag9050)), sonyEncipher(tag9050)},
+				{0x9400, 7, uint32(len(tag9400)), sonyEncipher(tag9400)},
+				{0xb020, 2, 9, []byte("Standard\x00")},
+				{0xb027, 4, 1, []byte{0, 0, 0xff, 0xff}},
+				{0xb02a, 1, 8, []byte{0x00, 0x00, 0x18, 0x01, 0x35, 0x35, 0x56, 0x00}},
+			})...)
+
+			e, entry := makerNoteExtractor(note)
+			e.Context = &helpers.ParseContext{}
+			detection, parsed, err := DetectAndParse(e, entry, "SONY")
+			if err != nil || detection.Manufacturer != "Sony" {
+				t.Fatalf("DetectAndParse() = %+v, %v", detection, err)
+			}
+
+			sony, ok := parsed.(*SonyMakerNote)
+			if !ok {
+				t.Fatalf("parsed is %T, want *SonyMakerNote", parsed)
+			}
+			want := SonyMakerNote{
+				Quality:                "Super Fine",
+				CreativeStyle:          "Standard",
+				LensType:               "E-Mount, T-Mount, Other Lens or no lens",
+				LensSpec:               "18-135mm F3.5-5.6",
+				ShutterCount:           0x001234,
+				InternalSerialNumber:   test.serialHex,
+				SequenceImageNumber:    3,
+				ShotNumberSincePowerUp: 57,
+			}
+			if *sony != want {
+				t.Errorf("parsed = %+v, want %+v", *sony, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package exif
+
+import "log/slog"
+
+// Options Optional behaviour for ExtractExifDataWithOptions
+type Options struct {
+	// AllTags returns every IFD entry, including unknown and private tags, in PhotoExifEvidence.Tags
+	AllTags bool
+	// Logger receives debug output and every diagnostic, slog.Default() when nil
+	Logger *slog.Logger
+}
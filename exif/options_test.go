@@ -0,0 +1,128 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestAllTags(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		t.Run(fmt.Sprint(order), func(t *testing.T) {
+			tiff := buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+				ifd0, exif, gps := evidenceIFDs(order, pointer, 2)
+				ifd0 = append(ifd0,
+					// A private tag nothing decodes, and one whose value offset lies past the end of the file
+					tiffEntry{0xc5d8, 3, 2, order.AppendUint16(order.AppendUint16(nil, 7), 9)},
+					tiffEntry{0xc5d9, 4, 4, order.AppendUint32(nil, 1<<20)},
+				)
+				return [][]tiffEntry{ifd0, {}, exif, gps}
+			})
+			opts := Options{Logger: slog.New(slog.DiscardHandler)}
+			metadata, err := ExtractExifDataWithOptions(tiff, opts)
+			checkEvidence(t, metadata, err)
+			if metadata.Tags != nil {
+				t.Errorf("Tags recorded without AllTags: %v", metadata.Tags)
+			}
+
+			opts.AllTags = true
+			metadata, err = ExtractExifDataWithOptions(tiff, opts)
+			checkEvidence(t, metadata, err)
+
+			got := map[string]string{}
+			for _, tag := range metadata.Tags {
+				value := tag.String()
+				if tag.Error != "" {
+					value = "error"
+				}
+				got[fmt.Sprintf("%s %#04x %s", tag.IFD, uint16(tag.Tag), tag.Type)] = value
+			}
+			want := map[string]string{
+				"IFD0 0x010f ASCII":    "Canon",
+				"IFD0 0xc5d8 SHORT":    "7 9",
+				"IFD0 0xc5d9 LONG":     "error",
+				"ExifIFD 0x9003 ASCII": "2024:10:01 14:58:52",
+				"GPS 0x0002 RATIONAL":  "51/1 30/1 15/1",
+				"GPS 0x0003 ASCII":     "W",
+			}
+			for key, value := range want {
+				if got[key] != value {
+					t.Errorf("%s = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+// withEntry returns entries with the entry for the same tag replaced by e
+func withEntry(entries []tiffEntry, e tiffEntry) []tiffEntry {
+	out := append([]tiffEntry{}, entries...)
+	for i := range out {
+		if out[i].tag == e.tag {
+			out[i] = e
+		}
+	}
+	return out
+}
+
+func TestDiagnostics(t *testing.T) {
+	order := binary.BigEndian
+	rational := func(v ...uint32) []byte {
+		var b []byte
+		for _, x := range v {
+			b = order.AppendUint32(order.AppendUint32(b, x), 1)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+		// ifd is 0 for IFD0, 2 for the Exif IFD and 3 for GPS
+		ifd   int
+		entry tiffEntry
+		want  helpers.Diagnostic
+	}{
+		{"latitude out of range", 3, tiffEntry{0x0002, 5, 3, rational(95, 0, 0)},
+			helpers.Diagnostic{Code: helpers.DiagInvalidValue, Severity: helpers.SeverityWarning, IFD: "GPS", Tag: 0x0002, Message: "GPS latitude out of valid range"}},
+		{"invalid date", 2, tiffEntry{0x9003, 2, 20, []byte("2024:13:45 99:00:00\x00")},
+			helpers.Diagnostic{Code: helpers.DiagInvalidDate, Severity: helpers.SeverityWarning, IFD: "ExifIFD", Tag: 0x9003, Message: "invalid DateTimeOriginal"}},
+		{"value out of range", 0, tiffEntry{0x010f, 2, 6, order.AppendUint32(nil, 1<<20)},
+			helpers.Diagnostic{Code: helpers.DiagOutOfRange, Severity: helpers.SeverityWarning, IFD: "IFD0", Tag: 0x010f, Message: "tag 0x010f value"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiff := buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+				ifd0, exif, gps := evidenceIFDs(order, pointer, 2)
+				ifds := [][]tiffEntry{ifd0, {}, exif, gps}
+				ifds[tt.ifd] = withEntry(ifds[tt.ifd], tt.entry)
+				return ifds
+			})
+
+			var logged bytes.Buffer
+			metadata, err := ExtractExifDataWithOptions(tiff, Options{Logger: slog.New(slog.NewTextHandler(&logged, nil))})
+			if err != nil {
+				t.Fatalf("ExtractExifData() error = %v", err)
+			}
+
+			if len(metadata.Warnings) != 1 {
+				t.Fatalf("Warnings = %v, want one", metadata.Warnings)
+			}
+			got := metadata.Warnings[0]
+			if got.Code != tt.want.Code || got.Severity != tt.want.Severity || got.IFD != tt.want.IFD || got.Tag != tt.want.Tag ||
+				got.Offset < 0 || !strings.HasPrefix(got.Message, tt.want.Message) {
+				t.Errorf("Warnings[0] = %+v, want %+v", got, tt.want)
+			}
+
+			// The injected logger receives the same diagnostic
+			if line := logged.String(); !strings.Contains(line, "level=WARN") || !strings.Contains(line, "code="+tt.want.Code) ||
+				!strings.Contains(line, tt.want.Message) {
+				t.Errorf("logged %q", line)
+			}
+		})
+	}
+}
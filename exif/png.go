@@ -0,0 +1,56 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// extractPNG decodes the EXIF, XMP and text chunks of a PNG file. PNGs without EXIF still return
+// their text chunks and dimensions.
+func extractPNG(src *helpers.Source, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, error) {
+	png, err := helpers.ReadPNGMetadata(src, ctx)
+	if png == nil {
+		return nil, err
+	}
+	if err != nil {
+		ctx.Warn(helpers.DiagTruncated, "PNG", -1, "PNG chunk list is incomplete, using chunks found so far: "+err.Error())
+	}
+
+	if png.Exif == nil && png.XMP == "" && len(png.Text) == 0 {
+		return nil, errors.New("cannot find EXIF, XMP or text chunks")
+	}
+
+	metadata := &helpers.PhotoExifEvidence{}
+	if png.Exif != nil {
+		// Some writers keep the JPEG APP1 signature in front of the TIFF header. An eXIf chunk is
+		// decoded in place, and a raw profile from the bytes it decoded to.
+		source, offset := helpers.NewBytesSource(png.Exif), 0
+		if png.ExifOffset >= 0 {
+			source, offset = src, png.ExifOffset
+		}
+		tiffStart := offset
+		if bytes.HasPrefix(png.Exif, []byte(helpers.ExifSignature)) {
+			tiffStart += len(helpers.ExifSignature)
+		}
+
+		decoded, _, err := decodeTIFFSection(source, offset, len(png.Exif), tiffStart, ctx)
+		if err != nil {
+			ctx.Error(helpers.DiagDecodeFailed, "PNG", png.ExifOffset, "cannot decode PNG EXIF data: "+err.Error())
+		} else {
+			metadata = decoded
+		}
+	}
+
+	if metadata.Image.Width == 0 && metadata.Image.Height == 0 {
+		metadata.Image.Width = png.Width
+		metadata.Image.Height = png.Height
+	}
+	if png.XMP != "" {
+		applyXMP(ctx, metadata, png.XMP)
+	}
+	metadata.TextChunks = png.Text
+
+	return metadata, nil
+}
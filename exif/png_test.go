@@ -0,0 +1,77 @@
+package exif
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+// pngChunk encodes a chunk with its length and CRC
+func pngChunk(chunkType string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(append(out, chunkType...), data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[4:]))
+}
+
+// pngFile wraps chunks between the signature, a 640x480 IHDR and IEND
+func pngFile(chunks ...[]byte) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, 640)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 480)
+	out := append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("IHDR", append(ihdr, 8, 2, 0, 0, 0))...)
+	for _, chunk := range chunks {
+		out = append(out, chunk...)
+	}
+	return append(out, pngChunk("IEND", nil)...)
+}
+
+func deflate(text string) []byte {
+	var out bytes.Buffer
+	w := zlib.NewWriter(&out)
+	w.Write([]byte(text))
+	w.Close()
+	return out.Bytes()
+}
+
+func TestExtractPNG(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		tiff := evidenceTIFF(order)
+		// ImageMagick writes the profile name, its length and the bytes as hex
+		profile := fmt.Sprintf("\nexif\n%8d\n%s\n", len(tiff), hex.EncodeToString(tiff))
+
+		tests := []struct {
+			name   string
+			chunks [][]byte
+			text   map[string]string
+		}{
+			{"eXIf", [][]byte{pngChunk("eXIf", tiff)}, map[string]string{}},
+			{"eXIf with signature", [][]byte{pngChunk("eXIf", append([]byte("Exif\x00\x00"), tiff...))}, map[string]string{}},
+			{"legacy raw profile", [][]byte{
+				pngChunk("zTXt", append([]byte("Raw profile type exif\x00\x00"), deflate(profile)...)),
+			}, map[string]string{}},
+			{"text chunks", [][]byte{
+				pngChunk("tEXt", []byte("Author\x00Jane")),
+				pngChunk("zTXt", append([]byte("Comment\x00\x00"), deflate("compressed")...)),
+				pngChunk("iTXt", []byte("Title\x00\x00\x00en\x00Titel\x00Harbour")),
+				pngChunk("iTXt", append([]byte("Description\x00\x01\x00\x00\x00"), deflate("inflated")...)),
+				pngChunk("eXIf", tiff),
+			}, map[string]string{"Author": "Jane", "Comment": "compressed", "Title": "Harbour", "Description": "inflated"}},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%v/%s", order, tt.name), func(t *testing.T) {
+				metadata, err := ExtractExifData(pngFile(tt.chunks...))
+				checkEvidence(t, metadata, err)
+				if !reflect.DeepEqual(metadata.TextChunks, tt.text) {
+					t.Errorf("TextChunks = %v, want %v", metadata.TextChunks, tt.text)
+				}
+				if len(metadata.Warnings) != 0 {
+					t.Errorf("unexpected warnings: %v", metadata.Warnings)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// countingReaderAt serves a file of the given size whose leading bytes are head and the rest zeros,
+// counting how many bytes are read
+type countingReaderAt struct {
+	head []byte
+	size int64
+	read int64
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	n := int(min(int64(len(p)), r.size-off))
+	clear(p[:n])
+	if off < int64(len(r.head)) {
+		copy(p[:n], r.head[off:])
+	}
+	r.read += int64(n)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// seekOnly hides the ReadAt method of a bytes.Reader
+type seekOnly struct {
+	io.ReadSeeker
+}
+
+func TestReaderMatchesBytes(t *testing.T) {
+	corpus := hostileCorpus(t)
+	corpus["synthetic.tif"] = syntheticTIFF(binary.LittleEndian)
+	corpus["synthetic.jpg"] = wrapJPEG(syntheticTIFF(binary.BigEndian))
+
+	opts := Options{AllTags: true, Logger: slog.New(slog.DiscardHandler)}
+	encode := func(metadata any, err error) string {
+		out, _ := json.Marshal(metadata)
+		if err != nil {
+			return string(out) + " " + err.Error()
+		}
+		return string(out)
+	}
+
+	for name, data := range corpus {
+		want := encode(ExtractExifDataWithOptions(data, opts))
+		if got := encode(ExtractExifDataFromReader(bytes.NewReader(data), int64(len(data)), opts)); got != want {
+			t.Errorf("%s: ReaderAt result differs\n got: %.300s\nwant: %.300s", name, got, want)
+		}
+		if got := encode(ExtractExifDataFromReadSeeker(seekOnly{bytes.NewReader(data)}, opts)); got != want {
+			t.Errorf("%s: ReadSeeker result differs\n got: %.300s\nwant: %.300s", name, got, want)
+		}
+	}
+}
+
+func TestReaderReadsOnlyMetadata(t *testing.T) {
+	const size = 200 << 20
+
+	// A JPEG whose scan data runs on for the rest of the file
+	jpeg := wrapJPEG(syntheticTIFF(binary.BigEndian))
+	jpeg = append(jpeg[:len(jpeg)-2], 0xff, 0xda, 0x00, 0x08, 0x01, 0x01, 0x00, 0x00, 0x3f, 0x00)
+
+	for name, head := range map[string][]byte{
+		"tiff": syntheticTIFF(binary.LittleEndian),
+		"jpeg": jpeg,
+	} {
+		r := &countingReaderAt{head: head, size: size}
+		// The synthetic files claim to be HDR+ without the XMP to back it, which is reported as an error
+		metadata, err := ExtractExifDataFromReader(r, size, Options{Logger: slog.New(slog.DiscardHandler)})
+		if metadata == nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if metadata.Device.Make != "Apple" || metadata.GPS.Latitude == 0 || len(metadata.Thumbnail.Data) == 0 {
+			t.Errorf("%s: metadata incomplete: make %q, latitude %v, thumbnail %d bytes", name, metadata.Device.Make, metadata.GPS.Latitude, len(metadata.Thumbnail.Data))
+		}
+		if r.read > 64<<10 {
+			t.Errorf("%s: read %d bytes of a %d byte file", name, r.read, size)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package exif
 
 import (
 	"fmt"
-	"log/slog"
 	"time"
 
 	"github.com/ZanyLeonic/exif-reader/exif/helpers"
@@ -36,6 +35,7 @@ const (
 	PixelXDimension         helpers.Tag = 0xa002
 	PixelYDimension         helpers.Tag = 0xa003
 	RelatedSoundFile        helpers.Tag = 0xa004
+	InteropIFD              helpers.Tag = 0xa005
 	FileSource              helpers.Tag = 0xa300
 	SceneType               helpers.Tag = 0xa301
 	WhiteBalance            helpers.Tag = 0xa403
@@ -59,139 +59,143 @@ const (
 )
 
 func ExtractExifSubIFD(exifIfdOffset int, metadata *helpers.PhotoExifEvidence, helper *helpers.ValueExtractor) {
-	entryCount := helper.Endian.Uint16(helper.Data[exifIfdOffset : exifIfdOffset+2])
-	for j := 0; j < int(entryCount); j++ {
-		entryOffset := exifIfdOffset + 2 + (j * 12)
-		entry := helpers.ParseIFDEntry(helper.Data, entryOffset, helper.Endian)
-
-		slog.Debug("ExifIFD Entry",
-			"tag", fmt.Sprintf("%#x", entry.Tag),
-			"type", entry.DataType,
-			"count", entry.Count,
-			"valueOffset", entry.ValueOffset)
-
+	// The MakerNote value contains its own IFD, so it is not claimed as a flat value
+	_, err := helper.WalkIFD("ExifIFD", exifIfdOffset, func(entry helpers.IFDEntry, value helpers.TagValue) {
 		switch entry.Tag {
 		case ExposureTime:
-			num, den := helper.GetRationalParts(entry, 0)
-			metadata.Camera.ExposureTime = helpers.FormatExposureTime(num, den)
+			num, den := value.Rational(0)
+			metadata.Camera.ExposureTime = helpers.FormatExposureTime(uint32(num), uint32(den))
 		case FNumber:
-			metadata.Camera.FNumber = helper.GetRational(entry, 0, false)
+			metadata.Camera.FNumber = value.Float(0)
 		case ExposureProgram:
-			metadata.Camera.ExposureProgram = helpers.ParseExposureProgram(helper.GetUint16(entryOffset))
+			metadata.Camera.ExposureProgram = helpers.ParseExposureProgram(uint16(value.Int(0)))
 		case ISO:
-			metadata.Camera.ISO = int(helper.GetUint16(entryOffset))
+			metadata.Camera.ISO = int(value.Int(0))
 		case ExifVersion:
-			metadata.Image.ExifVersion = helper.GetVersion(entry, entryOffset)
+			metadata.Image.ExifVersion = helpers.FormatVersion(value.Bytes())
 		case DateCaptured:
-			dateStr := helper.GetString(entry, entryOffset)
+			dateStr := value.String()
 			captured, err := time.Parse("2006:01:02 15:04:05", dateStr)
 			if err != nil {
-				slog.Warn("Found capture timestamp, but it is an invalid format!", "captureDate", dateStr, "error", err)
-				continue
+				helper.Context.WarnTag(helpers.DiagInvalidDate, "ExifIFD", entry, "invalid DateTimeOriginal: "+err.Error(), "captureDate", dateStr)
+				return
 			}
 			metadata.Temporal.DateCaptured = captured
 		case CreateDate:
-			dateStr := helper.GetString(entry, entryOffset)
+			dateStr := value.String()
 			captured, err := time.Parse("2006:01:02 15:04:05", dateStr)
 			if err != nil {
-				slog.Warn("Found createdate timestamp, but it is an invalid format!", "createDate", dateStr, "error", err)
-				continue
+				helper.Context.WarnTag(helpers.DiagInvalidDate, "ExifIFD", entry, "invalid CreateDate: "+err.Error(), "createDate", dateStr)
+				return
 			}
 			metadata.Temporal.CreateDate = captured
 		case OffsetTime:
-			metadata.Temporal.OffsetTime = helper.GetString(entry, entryOffset)
+			metadata.Temporal.OffsetTime = value.String()
 		case OffsetTimeOriginal:
-			metadata.Temporal.OffsetTimeOriginal = helper.GetString(entry, entryOffset)
+			metadata.Temporal.OffsetTimeOriginal = value.String()
 		case OffsetTimeDigitized:
-			metadata.Temporal.OffsetTimeDigitized = helper.GetString(entry, entryOffset)
+			metadata.Temporal.OffsetTimeDigitized = value.String()
 		case ComponentsConfiguration:
 			if entry.Count == 4 {
-				components := helper.GetUint8Array(entryOffset, 4)
-				metadata.Image.ComponentsConfig = helpers.ParseComponentsConfiguration(components)
+				metadata.Image.ComponentsConfig = helpers.ParseComponentsConfiguration(value.Bytes())
 			}
 		case MeteringMode:
-			metadata.Camera.MeteringMode = helpers.ParseMeteringMode(helper.GetUint16(entryOffset))
+			metadata.Camera.MeteringMode = helpers.ParseMeteringMode(uint16(value.Int(0)))
 		case LightSource:
-			metadata.Camera.LightSource = helpers.ParseLightSource(helper.GetUint16(entryOffset))
+			metadata.Camera.LightSource = helpers.ParseLightSource(uint16(value.Int(0)))
 		case FlashFired:
-			metadata.Camera.FlashFired = helpers.ParseFlashValue(helper.GetUint16(entryOffset))
+			metadata.Camera.FlashFired = helpers.ParseFlashValue(uint16(value.Int(0)))
 		case FocalLength:
-			metadata.Camera.FocalLength = helper.GetRational(entry, 0, false)
+			metadata.Camera.FocalLength = value.Float(0)
 		case MakerNote:
-			manufacturer, parsed, err := makernotes.DetectAndParse(helper, entry)
+			detection, parsed, err := makernotes.DetectAndParse(helper, entry, metadata.Device.Make)
 			if err != nil {
-				slog.Warn("Cannot parse MakerNote, skipping", "err", err)
-				continue
+				helper.Context.WarnTag(helpers.DiagDecodeFailed, "MakerNotes", entry, "cannot parse MakerNote, keeping it raw: "+err.Error())
+				// Whatever a plain IFD reading recovers is kept alongside the bytes
+				if generic := makernotes.ParseGeneric(helper, entry); generic != nil {
+					parsed = generic
+				}
 			}
 			metadata.Authenticity.MakerNote = helpers.MakerNoteData{
-				Raw:          helper.GetByteArray(entry, helper.TiffStart+int(entry.ValueOffset)),
-				Manufacturer: manufacturer,
-				Parsed:       *parsed,
+				Raw:          value.Bytes(),
+				Manufacturer: detection.Manufacturer,
+				MatchedBy:    detection.Reason,
+				Parsed:       parsed,
+			}
+			if dji, ok := parsed.(*makernotes.DJIMakerNote); ok {
+				applyDJIMakerNote(metadata, dji)
 			}
 		case UserComment:
-			metadata.Authorship.UserComment = helper.GetUserComment(entry, entryOffset)
+			metadata.Authorship.UserComment = helpers.DecodeUserComment(value.Bytes())
 		case SubSecTime:
-			metadata.Temporal.SubSecTime = helper.GetString(entry, entryOffset)
+			metadata.Temporal.SubSecTime = value.String()
 		case SubSecTimeOriginal:
-			metadata.Temporal.SubSecTimeOriginal = helper.GetString(entry, entryOffset)
+			metadata.Temporal.SubSecTimeOriginal = value.String()
 		case SubSecTimeDigitized:
-			metadata.Temporal.SubSecTimeDigitized = helper.GetString(entry, entryOffset)
+			metadata.Temporal.SubSecTimeDigitized = value.String()
 		case FlashpixVersion:
-			metadata.Image.FlashpixVersion = helper.GetVersion(entry, entryOffset)
+			metadata.Image.FlashpixVersion = helpers.FormatVersion(value.Bytes())
 		case ColorSpace:
-			metadata.Image.ColorSpace = helpers.ParseColourSpace(helper.GetUint16(entryOffset))
+			metadata.Image.ColorSpace = helpers.ParseColourSpace(uint16(value.Int(0)))
 		case PixelXDimension:
-			metadata.Image.PixelXDimension = float64(helper.GetUint16(entryOffset))
+			metadata.Image.PixelXDimension = float64(value.Int(0))
 		case PixelYDimension:
-			metadata.Image.PixelYDimension = float64(helper.GetUint16(entryOffset))
+			metadata.Image.PixelYDimension = float64(value.Int(0))
 		case RelatedSoundFile:
-			metadata.Authenticity.RelatedSoundFile = helper.GetString(entry, entryOffset)
+			metadata.Authenticity.RelatedSoundFile = value.String()
+		case InteropIFD:
+			interopIfdPointer := uint32(value.Int(0))
+			interopIfdOffset := helper.TiffStart + int(interopIfdPointer)
+			ExtractInteropIFD(interopIfdOffset, metadata, helper)
 		case FileSource:
-			metadata.Image.FileSource = helpers.ParseFileSource(helper.GetUint8(entryOffset))
+			metadata.Image.FileSource = helpers.ParseFileSource(uint8(value.Int(0)))
 		case SceneType:
-			rawVal := helper.GetUint8(entryOffset)
+			rawVal := uint8(value.Int(0))
 			if rawVal == 0x01 {
 				metadata.Image.SceneType = "Directly Photographed"
 			} else {
 				metadata.Image.SceneType = "Unknown"
 			}
 		case WhiteBalance:
-			metadata.Camera.WhiteBalance = helper.GetString(entry, entryOffset)
+			metadata.Camera.WhiteBalance = helpers.ParseWhiteBalance(uint16(value.Int(0)))
 		case DigitalZoomRatio:
-			metadata.Processing.DigitalZoomRatio = helper.GetRational(entry, 0, false)
+			metadata.Processing.DigitalZoomRatio = value.Float(0)
 		case SceneCaptureType:
-			metadata.Camera.SceneCaptureType = helpers.ParseSceneType(helper.GetUint16(entryOffset))
+			metadata.Camera.SceneCaptureType = helpers.ParseSceneType(uint16(value.Int(0)))
 		case Contrast:
-			metadata.Processing.Contrast = helpers.ParseProcessing(helper.GetUint16(entryOffset))
+			metadata.Processing.Contrast = helpers.ParseProcessing(uint16(value.Int(0)))
 		case Saturation:
-			metadata.Processing.Saturation = helpers.ParseProcessing(helper.GetUint16(entryOffset))
+			metadata.Processing.Saturation = helpers.ParseProcessing(uint16(value.Int(0)))
 		case Sharpness:
-			metadata.Processing.Sharpness = helpers.ParseProcessing(helper.GetUint16(entryOffset))
+			metadata.Processing.Sharpness = helpers.ParseProcessing(uint16(value.Int(0)))
 		case SubjectDistanceRange:
-			metadata.Camera.SubjectDistanceRange = helpers.ParseSubjectDistanceRange(helper.GetUint16(entryOffset))
+			metadata.Camera.SubjectDistanceRange = helpers.ParseSubjectDistanceRange(uint16(value.Int(0)))
 		case ImageUniqueID:
-			metadata.Authenticity.ImageUniqueID = helper.GetString(entry, entryOffset)
+			metadata.Authenticity.ImageUniqueID = value.String()
 		case BodySerialNumber:
-			metadata.Device.BodySerialNumber = helper.GetString(entry, entryOffset)
+			metadata.Device.BodySerialNumber = value.String()
 		case LensInfo:
-			metadata.Device.LensInfo = helper.GetString(entry, entryOffset)
+			metadata.Device.LensInfo = helpers.FormatLensInfo(value.Floats())
 		case LensMake:
-			metadata.Device.LensMake = helper.GetString(entry, entryOffset)
+			metadata.Device.LensMake = value.String()
 		case LensModel:
-			metadata.Device.LensModel = helper.GetString(entry, entryOffset)
+			metadata.Device.LensModel = value.String()
 		case LensSerialNumber:
-			metadata.Device.LensSerialNumber = helper.GetString(entry, entryOffset)
+			metadata.Device.LensSerialNumber = value.String()
 		case ImageEditor:
-			metadata.Processing.ImageEditor = helper.GetString(entry, entryOffset)
+			metadata.Processing.ImageEditor = value.String()
 		case CameraFirmware:
-			metadata.Device.CameraFirmware = helper.GetString(entry, entryOffset)
+			metadata.Device.CameraFirmware = value.String()
 		case CompositeImage:
-			metadata.Processing.CompositeImage = helpers.ParseCompositeImage(helper.GetUint16(entryOffset))
+			metadata.Processing.CompositeImage = helpers.ParseCompositeImage(uint16(value.Int(0)))
 		case CompositeImageCount:
-			sourceNum, usedNum := helper.GetCompositeImageCount(entry, entryOffset)
+			sourceNum, usedNum := value.Int(0), value.Int(1)
 			metadata.Processing.CompositeImageCount = fmt.Sprintf("%d/%d", sourceNum, usedNum)
 		case SerialNumber:
-			metadata.Device.SerialNumber = helper.GetString(entry, entryOffset)
+			metadata.Device.SerialNumber = value.String()
 		}
+	}, MakerNote)
+	if err != nil {
+		helper.Context.IFDError("ExifIFD", exifIfdOffset, err)
 	}
 }
@@ -0,0 +1,59 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+func TestUnparsedMakerNote(t *testing.T) {
+	order := binary.BigEndian
+	// A bare IFD whose only value fits in its entry
+	bareIFD := order.AppendUint16(nil, 1)
+	bareIFD = append(order.AppendUint32(order.AppendUint16(order.AppendUint16(bareIFD, 0x0001), 3), 1), 0, 2, 0, 0)
+	bareIFD = order.AppendUint32(bareIFD, 0)
+
+	tests := []struct {
+		name         string
+		make         string
+		makerNote    []byte
+		manufacturer string
+		parsed       helpers.MakerNoteFields
+	}{
+		{"unknown vendor", "Leica", bareIFD, "Unknown", helpers.GenericMakerNote{"0x0001": []uint16{2}}},
+		{"not an IFD", "Leica", []byte("GADGET\x00\x00\x00\x00\x00\x00"), "Unknown", nil},
+		// Too short for the IFD offset after the signature
+		{"parser error", "FUJIFILM", []byte("FUJIFILM\x0c\x00"), "Fujifilm", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiff := buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+				return [][]tiffEntry{
+					{{0x010f, 2, uint32(len(tt.make) + 1), []byte(tt.make + "\x00")}, {0x8769, 4, 1, pointer(2)}},
+					{},
+					{{0x927c, 7, uint32(len(tt.makerNote)), tt.makerNote}},
+				}
+			})
+
+			metadata, err := ExtractExifDataWithOptions(tiff, Options{Logger: slog.New(slog.DiscardHandler)})
+			if err != nil {
+				t.Fatalf("ExtractExifData() error = %v", err)
+			}
+
+			makerNote := metadata.Authenticity.MakerNote
+			if !bytes.Equal(makerNote.Raw, tt.makerNote) || makerNote.Manufacturer != tt.manufacturer {
+				t.Errorf("MakerNote = %q from %q, want %q from %q", makerNote.Raw, makerNote.Manufacturer, tt.makerNote, tt.manufacturer)
+			}
+			if !reflect.DeepEqual(makerNote.Parsed, tt.parsed) {
+				t.Errorf("Parsed = %#v, want %#v", makerNote.Parsed, tt.parsed)
+			}
+			if len(metadata.Warnings) != 1 || metadata.Warnings[0].Code != helpers.DiagDecodeFailed {
+				t.Errorf("want one decode_failed warning, got %v", metadata.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package exif
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// IFD1 (Thumbnail) Tags
+const (
+	StripOffsets                helpers.Tag = 0x0111
+	StripByteCounts             helpers.Tag = 0x0117
+	XResolution                 helpers.Tag = 0x011a
+	YResolution                 helpers.Tag = 0x011b
+	ResolutionUnit              helpers.Tag = 0x0128
+	JPEGInterchangeFormat       helpers.Tag = 0x0201
+	JPEGInterchangeFormatLength helpers.Tag = 0x0202
+)
+
+func ExtractThumbnailIFD(ifd1Offset int, metadata *helpers.PhotoExifEvidence, helper *helpers.ValueExtractor) {
+	thumbnail := &metadata.Thumbnail
+	var subfileType, compression, jpegOffset, jpegLength uint32
+	var stripOffsets, stripByteCounts []uint32
+
+	_, err := helper.WalkIFD("IFD1", ifd1Offset, func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case NewSubfileType:
+			subfileType = uint32(value.Int(0))
+		case ImageWidth:
+			thumbnail.Width = int(value.Int(0))
+		case ImageHeight:
+			thumbnail.Height = int(value.Int(0))
+		case Compression:
+			compression = uint32(value.Int(0))
+			thumbnail.Compression = helpers.ParseCompression(compression)
+		case XResolution:
+			thumbnail.XResolution = value.Float(0)
+		case YResolution:
+			thumbnail.YResolution = value.Float(0)
+		case ResolutionUnit:
+			thumbnail.ResolutionUnit = helpers.ParseResolutionUnit(uint16(value.Int(0)))
+		case StripOffsets:
+			stripOffsets = value.Uint32s()
+		case StripByteCounts:
+			stripByteCounts = value.Uint32s()
+		case JPEGInterchangeFormat:
+			jpegOffset = uint32(value.Int(0))
+		case JPEGInterchangeFormatLength:
+			jpegLength = uint32(value.Int(0))
+		}
+	})
+	if err != nil {
+		helper.Context.IFDError("IFD1", ifd1Offset, err)
+	}
+
+	switch {
+	case jpegOffset != 0 && jpegLength != 0:
+		start := helper.TiffStart + int(jpegOffset)
+		if jpegLength > helpers.MaxBlobSize {
+			helper.Context.Warn(helpers.DiagOutOfRange, "IFD1", start, "thumbnail JPEG exceeds size limit", "length", jpegLength)
+			return
+		}
+		data, err := helper.Source.Slice(start, int(jpegLength))
+		if err != nil {
+			helper.Context.Warn(helpers.DiagOutOfRange, "IFD1", start, "thumbnail JPEG out of range: "+err.Error(), "length", jpegLength)
+			return
+		}
+		thumbnail.Offset = start
+		thumbnail.Length = int(jpegLength)
+		thumbnail.Data = data
+
+		// Trust the dimensions in the thumbnail's own frame header over IFD1
+		if width, height, ok := jpegDimensions(thumbnail.Data); ok {
+			thumbnail.Width, thumbnail.Height = width, height
+		}
+	case len(stripOffsets) > 0 && len(stripOffsets) == len(stripByteCounts):
+		// IFD1 of a TIFF or DNG may be a full image rather than a thumbnail. Only strips marked as a
+		// reduced-resolution image, or holding JPEG data, are taken.
+		if subfileType&1 == 0 && compression != 6 && compression != 7 {
+			helper.Context.Log().Debug("IFD1 strips are not a thumbnail, skipping", "subfileType", subfileType, "compression", compression)
+			return
+		}
+		total := 0
+		for _, count := range stripByteCounts {
+			total += int(count)
+		}
+		if total > helpers.MaxBlobSize {
+			helper.Context.Warn(helpers.DiagOutOfRange, "IFD1", helper.TiffStart+int(stripOffsets[0]), "thumbnail strips exceed size limit", "length", total)
+			return
+		}
+
+		// Uncompressed thumbnails are stored as strips, which are usually but not always contiguous
+		thumbnail.Data = make([]byte, 0, total)
+		thumbnail.Offset = helper.TiffStart + int(stripOffsets[0])
+		for i, stripOffset := range stripOffsets {
+			start := helper.TiffStart + int(stripOffset)
+			strip, err := helper.Source.Slice(start, int(stripByteCounts[i]))
+			if err != nil {
+				helper.Context.Warn(helpers.DiagOutOfRange, "IFD1", start, "thumbnail strip out of range: "+err.Error(), "strip", i, "length", stripByteCounts[i])
+				return
+			}
+			thumbnail.Data = append(thumbnail.Data, strip...)
+		}
+		thumbnail.Length = len(thumbnail.Data)
+	default:
+		return
+	}
+
+	sum := sha256.Sum256(thumbnail.Data)
+	thumbnail.SHA256 = hex.EncodeToString(sum[:])
+}
+
+// jpegDimensions reads the width and height from the first SOF segment of a JPEG thumbnail, whose
+// marker chain is its own rather than part of the file being read
+func jpegDimensions(data []byte) (int, int, bool) {
+	src := helpers.NewBytesSource(data)
+	segments, _ := helpers.ReadJPEGSegments(src)
+	for _, segment := range segments {
+		if segment.Marker < helpers.MarkerSOF0 || segment.Marker > helpers.MarkerSOF15 ||
+			segment.Marker == helpers.MarkerDHT || segment.Marker == helpers.MarkerJPG || segment.Marker == helpers.MarkerDAC {
+			continue
+		}
+
+		// Sample precision, then 16-bit height and width
+		payload := segment.Payload(src)
+		if len(payload) < 5 {
+			return 0, 0, false
+		}
+		height := int(payload[1])<<8 | int(payload[2])
+		width := int(payload[3])<<8 | int(payload[4])
+		return width, height, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,88 @@
+package exif
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// thumbnailTIFF builds a TIFF whose IFD1 holds ifd1 and is followed by data, which the entries can
+// point at through the offset passed to ifd1
+func thumbnailTIFF(ifd1 func(dataOffset []byte) []tiffEntry, data []byte) []byte {
+	tiff := buildTIFF(binary.BigEndian, func(pointer func(int) []byte) [][]tiffEntry {
+		return [][]tiffEntry{
+			{{0x010f, 2, 6, []byte("Canon\x00")}},
+			ifd1(pointer(2)),
+		}
+	})
+	return append(tiff, data...)
+}
+
+func TestExtractThumbnail(t *testing.T) {
+	u16 := func(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+	u32 := func(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+	// offsetPlus adds n to a big-endian offset
+	offsetPlus := func(offset []byte, n uint32) []byte { return u32(binary.BigEndian.Uint32(offset) + n) }
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xc0, 0x00, 0x0b, 0x08, 0x00, 0x10, 0x00, 0x20, 0x01, 0x01, 0x11, 0x00, 0xff, 0xd9}
+	strips := []byte("stripONEstripTWO")
+
+	tests := []struct {
+		name     string
+		tiff     []byte
+		want     []byte
+		warnings int
+	}{
+		{"JPEG", thumbnailTIFF(func(data []byte) []tiffEntry {
+			return []tiffEntry{{0x0103, 3, 1, u16(6)}, {0x0201, 4, 1, data}, {0x0202, 4, 1, u32(uint32(len(jpeg)))}}
+		}, jpeg), jpeg, 0},
+		{"reduced-resolution strips", thumbnailTIFF(func(data []byte) []tiffEntry {
+			return []tiffEntry{
+				{0x00fe, 4, 1, u32(1)},
+				{0x0103, 3, 1, u16(1)},
+				{0x0111, 4, 2, append(append([]byte{}, data...), offsetPlus(data, 8)...)},
+				{0x0117, 4, 2, append(u32(8), u32(8)...)},
+			}
+		}, strips), strips, 0},
+		// Without the reduced-resolution flag IFD1 is a second full image, as in some DNGs
+		{"full-resolution strips", thumbnailTIFF(func(data []byte) []tiffEntry {
+			return []tiffEntry{
+				{0x0103, 3, 1, u16(1)},
+				{0x0111, 4, 1, data},
+				{0x0117, 4, 1, u32(16)},
+			}
+		}, strips), nil, 0},
+		{"strips over the size limit", thumbnailTIFF(func(data []byte) []tiffEntry {
+			return []tiffEntry{
+				{0x00fe, 4, 1, u32(1)},
+				{0x0111, 4, 2, append(append([]byte{}, data...), data...)},
+				{0x0117, 4, 2, append(u32(helpers.MaxBlobSize), u32(16)...)},
+			}
+		}, strips), nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := ExtractExifDataWithOptions(tt.tiff, Options{Logger: slog.New(slog.DiscardHandler)})
+			if err != nil {
+				t.Fatalf("ExtractExifData() error = %v", err)
+			}
+			if string(metadata.Thumbnail.Data) != string(tt.want) {
+				t.Errorf("thumbnail = %q, want %q", metadata.Thumbnail.Data, tt.want)
+			}
+			if (metadata.Thumbnail.SHA256 != "") != (tt.want != nil) {
+				t.Errorf("SHA256 = %q", metadata.Thumbnail.SHA256)
+			}
+
+			warnings := 0
+			for _, warning := range metadata.Warnings {
+				if warning.Code == helpers.DiagOutOfRange {
+					warnings++
+				}
+			}
+			if warnings != tt.warnings {
+				t.Errorf("warnings = %v, want %d out of range", metadata.Warnings, tt.warnings)
+			}
+		})
+	}
+}
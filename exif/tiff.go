@@ -0,0 +1,168 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// TIFF image structure and DNG Tags
+const (
+	NewSubfileType            helpers.Tag = 0x00fe
+	BitsPerSample             helpers.Tag = 0x0102
+	Compression               helpers.Tag = 0x0103
+	PhotometricInterpretation helpers.Tag = 0x0106
+	SamplesPerPixel           helpers.Tag = 0x0115
+	SubIFDs                   helpers.Tag = 0x014a
+	DNGVersion                helpers.Tag = 0xc612
+	DNGBackwardVersion        helpers.Tag = 0xc613
+	UniqueCameraModel         helpers.Tag = 0xc614
+	LocalizedCameraModel      helpers.Tag = 0xc615
+	ColorMatrix1              helpers.Tag = 0xc621
+	ColorMatrix2              helpers.Tag = 0xc622
+	CameraCalibration1        helpers.Tag = 0xc623
+	CameraCalibration2        helpers.Tag = 0xc624
+	AsShotNeutral             helpers.Tag = 0xc628
+	BaselineExposure          helpers.Tag = 0xc62a
+	CalibrationIlluminant1    helpers.Tag = 0xc65a
+	CalibrationIlluminant2    helpers.Tag = 0xc65b
+)
+
+// extractTIFF decodes a standalone TIFF or DNG file. IFD0 goes through the usual EXIF pipeline, then
+// every IFD in the chain and any SubIFDs are recorded as images along with the DNG tags.
+func extractTIFF(src *helpers.Source, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, error) {
+	metadata, helper, err := decodeTIFF(src, 0, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ifd0Offset, _ := helpers.ParseTIFFHeader(src, 0)
+
+	type pendingIFD struct {
+		name    string
+		offset  uint32
+		inChain bool
+	}
+
+	// Loops and IFDs reached twice are caught by the parse context, which stops the walk there
+	queue := []pendingIFD{{name: "IFD0", offset: ifd0Offset, inChain: true}}
+	chainIndex := 0
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current.offset == 0 {
+			continue
+		}
+
+		image, next, subIFDs, err := decodeTIFFImageIFD(helper, current.offset, current.name, metadata)
+		// Loops are reported by the parse context, and IFD0 and IFD1 errors by the EXIF pass
+		if errors.Is(err, helpers.ErrIFDLoop) || (err != nil && (current.name == "IFD0" || current.name == "IFD1")) {
+			continue
+		}
+		if err != nil {
+			ctx.Error(helpers.DiagOutOfRange, current.name, int(current.offset), "cannot read TIFF IFD: "+err.Error())
+			continue
+		}
+		metadata.TIFF.Images = append(metadata.TIFF.Images, image)
+
+		for i, subIFD := range subIFDs {
+			queue = append(queue, pendingIFD{name: fmt.Sprintf("%s.SubIFD%d", current.name, i), offset: subIFD})
+		}
+
+		// Only the main chain is followed (IFD0 -> IFD1 -> ...), SubIFDs are read individually
+		if current.inChain && next != 0 {
+			chainIndex++
+			queue = append(queue, pendingIFD{name: fmt.Sprintf("IFD%d", chainIndex), offset: next, inChain: true})
+		}
+	}
+
+	return metadata, nil
+}
+
+// decodeTIFFImageIFD reads the image structure tags of one IFD along with any DNG tags it holds,
+// returning the next IFD offset in the chain and the offsets of its SubIFDs
+func decodeTIFFImageIFD(helper *helpers.ValueExtractor, ifdOffset uint32, name string, metadata *helpers.PhotoExifEvidence) (helpers.TIFFImage, uint32, []uint32, error) {
+	start := helper.TiffStart + int(ifdOffset)
+	image := helpers.TIFFImage{IFD: name, Offset: start}
+
+	ifd, err := helper.ReadIFD(name, start)
+	if err != nil && !errors.Is(err, helpers.ErrTruncated) {
+		return image, 0, nil, err
+	}
+	// IFD0 and IFD1 truncation is already reported by decodeTIFF and ExtractThumbnailIFD
+	if err != nil && name != "IFD0" && name != "IFD1" {
+		helper.Context.IFDError(name, start, err)
+	}
+
+	var subIFDs []uint32
+	dng := &metadata.TIFF.DNG
+
+	visit := func(entry helpers.IFDEntry, value helpers.TagValue) {
+		switch entry.Tag {
+		case NewSubfileType:
+			image.SubfileType = helpers.ParseSubfileType(uint32(value.Int(0)))
+		case ImageWidth:
+			image.Width = int(value.Int(0))
+		case ImageHeight:
+			image.Height = int(value.Int(0))
+		case BitsPerSample:
+			image.BitsPerSample = value.Uint32s()
+		case Compression:
+			image.Compression = helpers.ParseCompression(uint32(value.Int(0)))
+		case PhotometricInterpretation:
+			image.PhotometricInterpretation = helpers.ParsePhotometricInterpretation(uint32(value.Int(0)))
+		case SamplesPerPixel:
+			image.SamplesPerPixel = int(value.Int(0))
+		case SubIFDs:
+			subIFDs = value.Uint32s()
+		case DNGVersion:
+			dng.DNGVersion = formatDNGVersion(value.Bytes())
+		case DNGBackwardVersion:
+			dng.DNGBackwardVersion = formatDNGVersion(value.Bytes())
+		case UniqueCameraModel:
+			dng.UniqueCameraModel = value.String()
+		case LocalizedCameraModel:
+			dng.LocalizedCameraModel = value.String()
+		case ColorMatrix1:
+			dng.ColorMatrix1 = value.Floats()
+		case ColorMatrix2:
+			dng.ColorMatrix2 = value.Floats()
+		case CameraCalibration1:
+			dng.CameraCalibration1 = value.Floats()
+		case CameraCalibration2:
+			dng.CameraCalibration2 = value.Floats()
+		case AsShotNeutral:
+			dng.AsShotNeutral = value.Floats()
+		case BaselineExposure:
+			dng.BaselineExposure = value.Float(0)
+		case CalibrationIlluminant1:
+			dng.CalibrationIlluminant1 = helpers.ParseLightSource(uint16(value.Int(0)))
+		case CalibrationIlluminant2:
+			dng.CalibrationIlluminant2 = helpers.ParseLightSource(uint16(value.Int(0)))
+		}
+	}
+
+	// IFD0 and IFD1 entries are already recorded and reported by decodeTIFF and ExtractThumbnailIFD
+	if name == "IFD0" || name == "IFD1" {
+		for _, entry := range ifd.Entries {
+			if value, err := helper.Decode(entry); err == nil {
+				visit(entry, value)
+			}
+		}
+	} else {
+		helper.WalkEntries(name, ifd.Entries, visit)
+	}
+
+	return image, ifd.Next, subIFDs, nil
+}
+
+// formatDNGVersion renders the four version bytes, e.g. 1.4.0.0
+func formatDNGVersion(raw []uint8) string {
+	if len(raw) != 4 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", raw[0], raw[1], raw[2], raw[3])
+}
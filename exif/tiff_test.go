@@ -0,0 +1,87 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// dngTIFF builds a DNG whose IFD0 preview has a raw SubIFD and a reduced-resolution SubIFD. When
+// loop is set, the second SubIFD points back at IFD0.
+func dngTIFF(order binary.AppendByteOrder, loop bool) []byte {
+	u16 := func(v uint16) []byte { return order.AppendUint16(nil, v) }
+	u32 := func(v uint32) []byte { return order.AppendUint32(nil, v) }
+
+	return buildTIFF(order, func(pointer func(int) []byte) [][]tiffEntry {
+		ifd0, exif, gps := evidenceIFDs(order, pointer, 2)
+		ifd0 = append([]tiffEntry{
+			{0x00fe, 4, 1, u32(1)},
+			{0x0100, 3, 1, u16(256)},
+			ifd0[0],
+			{0x014a, 4, 2, append(pointer(4), pointer(5)...)},
+		}, ifd0[1:]...)
+		ifd0 = append(ifd0,
+			tiffEntry{0xc612, 1, 4, []byte{1, 4, 0, 0}},
+			tiffEntry{0xc614, 2, 10, []byte("Canon R5\x00\x00")},
+		)
+
+		reduced := []tiffEntry{{0x00fe, 4, 1, u32(1)}, {0x0100, 3, 1, u16(1024)}}
+		if loop {
+			reduced = append(reduced, tiffEntry{0x014a, 4, 1, u32(8)})
+		}
+
+		return [][]tiffEntry{
+			ifd0,
+			{{0x0100, 3, 1, u16(160)}},
+			exif,
+			gps,
+			{{0x00fe, 4, 1, u32(0)}, {0x0100, 4, 1, u32(8192)}, {0x0103, 3, 1, u16(7)}},
+			reduced,
+		}
+	})
+}
+
+func TestExtractTIFF(t *testing.T) {
+	// SubIFDs are read after the IFD pointing at them and before the next IFD in the chain
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		for _, loop := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%v/loop=%v", order, loop), func(t *testing.T) {
+				metadata, err := ExtractExifDataWithOptions(dngTIFF(order, loop), Options{Logger: slog.New(slog.DiscardHandler)})
+				checkEvidence(t, metadata, err)
+
+				want := []helpers.TIFFImage{
+					{IFD: "IFD0", SubfileType: "Reduced-resolution image", Width: 256},
+					{IFD: "IFD0.SubIFD0", SubfileType: "Full-resolution image", Width: 8192, Compression: helpers.ParseCompression(7)},
+					{IFD: "IFD0.SubIFD1", SubfileType: "Reduced-resolution image", Width: 1024},
+					{IFD: "IFD1", Width: 160},
+				}
+				if len(metadata.TIFF.Images) != len(want) {
+					t.Fatalf("Images = %+v, want %d", metadata.TIFF.Images, len(want))
+				}
+				for i, image := range metadata.TIFF.Images {
+					image.Offset = 0
+					if fmt.Sprint(image) != fmt.Sprint(want[i]) {
+						t.Errorf("Images[%d] = %+v, want %+v", i, image, want[i])
+					}
+				}
+
+				if metadata.TIFF.DNG.DNGVersion != "1.4.0.0" || metadata.TIFF.DNG.UniqueCameraModel != "Canon R5" {
+					t.Errorf("DNG = %+v", metadata.TIFF.DNG)
+				}
+
+				loops := 0
+				for _, warning := range metadata.Warnings {
+					if warning.Code == helpers.DiagIFDLoop {
+						loops++
+					}
+				}
+				if want := map[bool]int{false: 0, true: 1}[loop]; loops != want {
+					t.Errorf("IFD loop warnings = %d, want %d: %v", loops, want, metadata.Warnings)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// extractWebP decodes the EXIF and XMP chunks of a WebP file and reports the VP8X canvas and animation details
+func extractWebP(src *helpers.Source, ctx *helpers.ParseContext) (*helpers.PhotoExifEvidence, error) {
+	webp, err := helpers.ReadWebP(src)
+	if webp == nil {
+		return nil, err
+	}
+	if err != nil {
+		ctx.Warn(helpers.DiagTruncated, "WebP", -1, "WebP chunk list is incomplete, using chunks found so far: "+err.Error())
+	}
+
+	if webp.Exif == nil && webp.XMP == "" {
+		return nil, errors.New("cannot find EXIF or XMP chunks")
+	}
+
+	metadata := &helpers.PhotoExifEvidence{}
+	if webp.Exif != nil {
+		// Writers disagree on whether the chunk keeps the JPEG APP1 signature
+		tiffStart := webp.ExifOffset
+		if bytes.HasPrefix(webp.Exif, []byte(helpers.ExifSignature)) {
+			tiffStart += len(helpers.ExifSignature)
+		}
+
+		decoded, _, err := decodeTIFFSection(src, webp.ExifOffset, len(webp.Exif), tiffStart, ctx)
+		if err != nil {
+			ctx.Error(helpers.DiagDecodeFailed, "WebP", webp.ExifOffset, "cannot decode WebP EXIF chunk: "+err.Error())
+		} else {
+			metadata = decoded
+		}
+	}
+
+	if webp.XMP != "" {
+		applyXMP(ctx, metadata, webp.XMP)
+	}
+
+	metadata.Image.CanvasWidth = webp.CanvasWidth
+	metadata.Image.CanvasHeight = webp.CanvasHeight
+	metadata.Image.Animated = webp.Flags&helpers.VP8XFlagAnimation != 0
+	metadata.Image.HasAlpha = webp.Flags&helpers.VP8XFlagAlpha != 0
+	metadata.Image.AnimationFrames = webp.FrameCount
+	metadata.Image.AnimationLoopCount = webp.LoopCount
+
+	return metadata, nil
+}
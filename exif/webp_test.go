@@ -0,0 +1,64 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// riffChunk encodes a RIFF chunk, padded to an even length
+func riffChunk(fourCC string, data []byte) []byte {
+	out := binary.LittleEndian.AppendUint32([]byte(fourCC), uint32(len(data)))
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// webpFile wraps chunks in a RIFF WEBP container, starting with a VP8X header for a 640x480 canvas
+func webpFile(flags byte, chunks ...[]byte) []byte {
+	vp8x := []byte{flags, 0, 0, 0, 0x7f, 0x02, 0x00, 0xdf, 0x01, 0x00}
+	body := append([]byte("WEBP"), riffChunk("VP8X", vp8x)...)
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+	return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
+
+func TestExtractWebP(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		tiff := evidenceTIFF(order)
+		// A lossless bitstream header for the same canvas
+		vp8l := riffChunk("VP8L", []byte{0x2f, 0x7f, 0xc2, 0x77, 0x00})
+
+		tests := []struct {
+			name  string
+			webp  []byte
+			image helpers.ImageProperties
+		}{
+			{"still", webpFile(helpers.VP8XFlagExif|helpers.VP8XFlagAlpha, vp8l, riffChunk("EXIF", tiff)),
+				helpers.ImageProperties{CanvasWidth: 640, CanvasHeight: 480, HasAlpha: true}},
+			{"EXIF with signature", webpFile(helpers.VP8XFlagExif, vp8l, riffChunk("EXIF", append([]byte("Exif\x00\x00"), tiff...))),
+				helpers.ImageProperties{CanvasWidth: 640, CanvasHeight: 480}},
+			// Frames of odd length are padded, which the walk must skip to reach the EXIF chunk
+			{"animated", webpFile(helpers.VP8XFlagExif|helpers.VP8XFlagAnimation,
+				riffChunk("ANIM", []byte{0, 0, 0, 0, 3, 0}),
+				riffChunk("ANMF", make([]byte, 17)),
+				riffChunk("ANMF", make([]byte, 17)),
+				riffChunk("EXIF", tiff),
+			), helpers.ImageProperties{CanvasWidth: 640, CanvasHeight: 480, Animated: true, AnimationFrames: 2, AnimationLoopCount: 3}},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%v/%s", order, tt.name), func(t *testing.T) {
+				metadata, err := ExtractExifData(tt.webp)
+				checkEvidence(t, metadata, err)
+				if fmt.Sprint(metadata.Image) != fmt.Sprint(tt.image) {
+					t.Errorf("Image = %+v, want %+v", metadata.Image, tt.image)
+				}
+			})
+		}
+	}
+}
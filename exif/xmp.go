@@ -0,0 +1,20 @@
+package exif
+
+import (
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
+)
+
+// applyXMP stores the raw XMP packet on the evidence and decodes the fields we understand from it
+func applyXMP(ctx *helpers.ParseContext, metadata *helpers.PhotoExifEvidence, packet string) helpers.XmpMeta {
+	ctx.Log().Debug("Found XMP data", "xmp", packet)
+	metadata.XMP = packet
+
+	xmp, err := helpers.DecodeXMPMeta([]byte(packet))
+	if err != nil {
+		ctx.Warn(helpers.DiagDecodeFailed, "XMP", -1, err.Error())
+		return xmp
+	}
+
+	applyDroneXMP(ctx, metadata, xmp.RDF.Description.DroneDJIXMP)
+	return xmp
+}
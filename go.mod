@@ -2,4 +2,4 @@ module github.com/ZanyLeonic/exif-reader
 
 go 1.25.4
 
-require google.golang.org/protobuf v1.36.11 // indirect
+require google.golang.org/protobuf v1.36.11
@@ -1,26 +1,46 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"text/tabwriter"
 
 	"github.com/ZanyLeonic/exif-reader/exif"
+	"github.com/ZanyLeonic/exif-reader/exif/helpers"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		slog.Error("Usage: exif-reader <image-file>")
+	thumbnailPath := flag.String("thumbnail", "", "write the embedded EXIF thumbnail to this file")
+	allTags := flag.Bool("all-tags", false, "print every IFD entry, including unknown and private tags")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: exif-reader [flags] <image-file>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		slog.Error("Usage: exif-reader [flags] <image-file>")
+		os.Exit(1)
+	}
+
+	filename := flag.Arg(0)
+	file, err := os.Open(filename)
+	if err != nil {
+		slog.Error("Error reading file", "error", err, "file", filename)
 		os.Exit(1)
 	}
+	defer file.Close()
 
-	filename := os.Args[1]
-	data, err := os.ReadFile(filename)
+	info, err := file.Stat()
 	if err != nil {
 		slog.Error("Error reading file", "error", err, "file", filename)
 		os.Exit(1)
 	}
 
-	metadata, err := exif.ExtractExifData(data)
+	// Only the parts of the file holding metadata are read, which matters for large raw files
+	metadata, err := exif.ExtractExifDataFromReader(file, info.Size(), exif.Options{AllTags: *allTags})
 	if metadata != nil && err != nil {
 		slog.Warn("Extracted metadata with warnings", "warning", err)
 	} else if err != nil {
@@ -28,7 +48,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *thumbnailPath != "" {
+		if len(metadata.Thumbnail.Data) == 0 {
+			slog.Error("File has no embedded thumbnail", "file", filename)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(*thumbnailPath, metadata.Thumbnail.Data, 0o644); err != nil {
+			slog.Error("Error writing thumbnail", "error", err, "file", *thumbnailPath)
+			os.Exit(1)
+		}
+		slog.Info("Wrote thumbnail", "file", *thumbnailPath, "bytes", metadata.Thumbnail.Length, "sha256", metadata.Thumbnail.SHA256)
+	}
+
+	if *allTags {
+		printTags(metadata.Tags)
+		os.Exit(0)
+	}
+
 	slog.Info("Metadata search successful", "metadata", metadata)
 
 	os.Exit(0)
 }
+
+// printTags writes the raw tag dump as a table, truncating long values
+func printTags(tags []helpers.RawTag) {
+	const maxValue = 64
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IFD\tTAG\tTYPE\tCOUNT\tOFFSET\tVALUE")
+	for _, tag := range tags {
+		value := tag.Error
+		if value == "" {
+			value = tag.String()
+		}
+		if len(value) > maxValue {
+			value = value[:maxValue] + "..."
+		}
+		fmt.Fprintf(w, "%s\t%#04x\t%s\t%d\t%d\t%q\n", tag.IFD, uint16(tag.Tag), tag.Type, tag.Count, tag.Offset, value)
+	}
+	w.Flush()
+}
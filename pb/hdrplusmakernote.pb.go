@@ -202,10 +202,11 @@ func (x *FrameInfo) GetCreateDateInfo() *CreateDateInfo {
 }
 
 type CreateDateInfo struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	CreateDate    int64                  `protobuf:"varint,1,opt,name=create_date,json=createDate,proto3" json:"create_date,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CreateDate      int64                  `protobuf:"varint,1,opt,name=create_date,json=createDate,proto3" json:"create_date,omitempty"`
+	CreateDateNanos int32                  `protobuf:"varint,2,opt,name=create_date_nanos,json=createDateNanos,proto3" json:"create_date_nanos,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *CreateDateInfo) Reset() {
@@ -245,6 +246,13 @@ func (x *CreateDateInfo) GetCreateDate() int64 {
 	return 0
 }
 
+func (x *CreateDateInfo) GetCreateDateNanos() int32 {
+	if x != nil {
+		return x.CreateDateNanos
+	}
+	return 0
+}
+
 type DeviceInfo struct {
 	state                  protoimpl.MessageState `protogen:"open.v1"`
 	DeviceMake             string                 `protobuf:"bytes,1,opt,name=device_make,json=deviceMake,proto3" json:"device_make,omitempty"`
@@ -503,10 +511,11 @@ const file_hdrplusmakernote_proto_rawDesc = "" +
 	"\tFrameInfo\x12\x1f\n" +
 	"\vframe_count\x18\x03 \x01(\x05R\n" +
 	"frameCount\x12<\n" +
-	"\x10create_date_info\x18$ \x01(\v2\x12.pb.CreateDateInfoR\x0ecreateDateInfo\"1\n" +
+	"\x10create_date_info\x18$ \x01(\v2\x12.pb.CreateDateInfoR\x0ecreateDateInfo\"]\n" +
 	"\x0eCreateDateInfo\x12\x1f\n" +
 	"\vcreate_date\x18\x01 \x01(\x03R\n" +
-	"createDate\"\xf5\x03\n" +
+	"createDate\x12*\n" +
+	"\x11create_date_nanos\x18\x02 \x01(\x05R\x0fcreateDateNanos\"\xf5\x03\n" +
 	"\n" +
 	"DeviceInfo\x12\x1f\n" +
 	"\vdevice_make\x18\x01 \x01(\tR\n" +